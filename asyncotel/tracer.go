@@ -0,0 +1,93 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+// Package asyncotel adapts OpenTelemetry tracing to the tracer interface of the async package.
+//
+//	async.SetTracer(asyncotel.NewTracer(otel.Tracer("my-service")))
+package asyncotel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grab/async"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer adapts an OpenTelemetry tracer to async.Tracer
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer creates a new tracer which starts the spans of the tasks with the OpenTelemetry tracer.
+func NewTracer(tracer trace.Tracer) *Tracer {
+	return &Tracer{tracer: tracer}
+}
+
+// Start starts a span as a child of the span carried by the context, if any, and returns
+// a context carrying the new span.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, async.Span) {
+	ctx, s := t.tracer.Start(ctx, name)
+	return ctx, &span{span: s}
+}
+
+// ContextWithSpan returns a context carrying the span.
+func (t *Tracer) ContextWithSpan(ctx context.Context, s async.Span) context.Context {
+	if s, ok := s.(*span); ok {
+		return trace.ContextWithSpan(ctx, s.span)
+	}
+	return ctx
+}
+
+// span adapts an OpenTelemetry span to async.Span
+type span struct {
+	span trace.Span
+}
+
+// SetAttribute sets an attribute on the span.
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.span.SetAttributes(toAttribute(key, value))
+}
+
+// AddLink links the span to another span started by the adapter.
+func (s *span) AddLink(linked async.Span) {
+	if linked, ok := linked.(*span); ok {
+		s.span.AddLink(trace.Link{SpanContext: linked.span.SpanContext()})
+	}
+}
+
+// RecordError records the error and sets the status of the span accordingly.
+func (s *span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End ends the span.
+func (s *span) End() {
+	s.span.End()
+}
+
+// toAttribute converts a value to an OpenTelemetry attribute.
+func toAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case uint64:
+		return attribute.Int64(key, int64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	case time.Duration:
+		return attribute.Int64(key, int64(v))
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package asyncotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grab/async"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTracer() (*Tracer, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return NewTracer(provider.Tracer("asyncotel")), exporter
+}
+
+func spanOf(exporter *tracetest.InMemoryExporter, task async.Task) tracetest.SpanStub {
+	for _, s := range exporter.GetSpans() {
+		for _, attr := range s.Attributes {
+			if attr.Key == async.AttributeTaskID && attr.Value.AsInt64() == int64(task.ID()) {
+				return s
+			}
+		}
+	}
+	return tracetest.SpanStub{}
+}
+
+func TestTracer(t *testing.T) {
+	tracer, exporter := newTracer()
+	task := async.Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return nil, errors.New("some error")
+	}, async.WithTracer(tracer), async.WithName("traced"))
+	_, _ = task.Outcome()
+
+	s := spanOf(exporter, task)
+	assert.Equal(t, "traced", s.Name)
+	assert.Equal(t, codes.Error, s.Status.Code)
+	assert.Contains(t, s.Attributes, attribute.String(async.AttributeTaskState, "faulted"))
+	assert.Len(t, s.Events, 1)
+}
+
+func TestTracerForkJoin(t *testing.T) {
+	tracer, exporter := newTracer()
+	async.SetTracer(tracer)
+	defer async.SetTracer(nil)
+
+	work := func(context.Context) (interface{}, error) { return 1, nil }
+	tasks := async.NewTasks(work, work)
+	parent := async.ForkJoin(context.Background(), tasks)
+	_, _ = parent.Outcome()
+
+	parentSpan := spanOf(exporter, parent)
+	for _, task := range tasks {
+		s := spanOf(exporter, task)
+		assert.Equal(t, parentSpan.SpanContext.SpanID(), s.Parent.SpanID())
+		assert.Equal(t, parentSpan.SpanContext.TraceID(), s.SpanContext.TraceID())
+	}
+}
+
+func TestTracerBatch(t *testing.T) {
+	tracer, exporter := newTracer()
+	async.SetTracer(tracer)
+	defer async.SetTracer(nil)
+
+	b := async.NewBatch(context.Background(), func(input []interface{}) []interface{} {
+		return input
+	})
+	entries := []async.Task{b.Append(1), b.Append(2)}
+	b.Reduce()
+	async.WaitAll(entries)
+
+	var batchSpan tracetest.SpanStub
+	for _, s := range exporter.GetSpans() {
+		if len(s.Links) > 0 {
+			batchSpan = s
+		}
+	}
+
+	assert.Len(t, batchSpan.Links, 2)
+	for i, entry := range entries {
+		assert.Equal(t, spanOf(exporter, entry).SpanContext.SpanID(), batchSpan.Links[i].SpanContext.SpanID())
+	}
+}
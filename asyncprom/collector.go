@@ -0,0 +1,110 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+// Package asyncprom provides a Prometheus collector recording the metrics of the async combinators.
+// Register the collector and set it as the metrics of the combinators:
+//
+//	collector := asyncprom.NewCollector("my_service")
+//	prometheus.MustRegister(collector)
+//	async.SetMetrics(collector)
+package asyncprom
+
+import (
+	"time"
+
+	"github.com/grab/async"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector implementing async.Metrics.
+type Collector struct {
+	started   *prometheus.CounterVec
+	finished  *prometheus.CounterVec
+	dropped   *prometheus.CounterVec
+	inFlight  *prometheus.GaugeVec
+	queueWait *prometheus.HistogramVec
+	runTime   *prometheus.HistogramVec
+}
+
+// NewCollector creates a collector whose metrics are prefixed with the specified namespace, if any.
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		started: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "async",
+			Name:      "tasks_started_total",
+			Help:      "The number of tasks started by the combinators.",
+		}, []string{"combinator"}),
+		finished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "async",
+			Name:      "tasks_finished_total",
+			Help:      "The number of tasks started by the combinators which are done, by final state.",
+		}, []string{"combinator", "state"}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "async",
+			Name:      "tasks_dropped_total",
+			Help:      "The number of tasks cancelled by the combinators without being started.",
+		}, []string{"combinator"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "async",
+			Name:      "tasks_in_flight",
+			Help:      "The number of tasks started by the combinators which are not done yet.",
+		}, []string{"combinator"}),
+		queueWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "async",
+			Name:      "task_queue_wait_seconds",
+			Help:      "The time the tasks waited before being started by the combinators.",
+			Buckets:   async.DefaultBuckets,
+		}, []string{"combinator"}),
+		runTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "async",
+			Name:      "task_run_seconds",
+			Help:      "The run time of the tasks started by the combinators.",
+			Buckets:   async.DefaultBuckets,
+		}, []string{"combinator"}),
+	}
+}
+
+// Describe sends the descriptors of the metrics to the channel.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.started.Describe(ch)
+	c.finished.Describe(ch)
+	c.dropped.Describe(ch)
+	c.inFlight.Describe(ch)
+	c.queueWait.Describe(ch)
+	c.runTime.Describe(ch)
+}
+
+// Collect sends the metrics to the channel.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.started.Collect(ch)
+	c.finished.Collect(ch)
+	c.dropped.Collect(ch)
+	c.inFlight.Collect(ch)
+	c.queueWait.Collect(ch)
+	c.runTime.Collect(ch)
+}
+
+// TaskStarted records a task started by the combinator after waiting in its queue.
+func (c *Collector) TaskStarted(combinator string, queueWait time.Duration) {
+	c.started.WithLabelValues(combinator).Inc()
+	c.inFlight.WithLabelValues(combinator).Inc()
+	c.queueWait.WithLabelValues(combinator).Observe(queueWait.Seconds())
+}
+
+// TaskFinished records the final state and the run time of a task started by the combinator.
+func (c *Collector) TaskFinished(combinator string, state async.State, runTime time.Duration) {
+	c.finished.WithLabelValues(combinator, state.String()).Inc()
+	c.inFlight.WithLabelValues(combinator).Dec()
+	c.runTime.WithLabelValues(combinator).Observe(runTime.Seconds())
+}
+
+// TaskDropped records a task cancelled by the combinator without ever being started.
+func (c *Collector) TaskDropped(combinator string) {
+	c.dropped.WithLabelValues(combinator).Inc()
+}
@@ -0,0 +1,53 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package asyncprom
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/grab/async"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector("test")
+	registry := prometheus.NewPedanticRegistry()
+	assert.NoError(t, registry.Register(collector))
+
+	tasks := async.NewTasks(
+		func(context.Context) (interface{}, error) { return 1, nil },
+		func(context.Context) (interface{}, error) { return nil, errors.New("some error") },
+	)
+	_, err := async.InvokeAll(context.Background(), 1, tasks, async.WithMetrics(collector)).Outcome()
+	assert.NoError(t, err)
+
+	expected := `
+# HELP test_async_tasks_finished_total The number of tasks started by the combinators which are done, by final state.
+# TYPE test_async_tasks_finished_total counter
+test_async_tasks_finished_total{combinator="invoke_all",state="completed"} 1
+test_async_tasks_finished_total{combinator="invoke_all",state="faulted"} 1
+# HELP test_async_tasks_in_flight The number of tasks started by the combinators which are not done yet.
+# TYPE test_async_tasks_in_flight gauge
+test_async_tasks_in_flight{combinator="invoke_all"} 0
+# HELP test_async_tasks_started_total The number of tasks started by the combinators.
+# TYPE test_async_tasks_started_total counter
+test_async_tasks_started_total{combinator="invoke_all"} 2
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"test_async_tasks_started_total", "test_async_tasks_finished_total", "test_async_tasks_in_flight"))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "test_async_task_run_seconds"))
+}
+
+func TestCollectorDropped(t *testing.T) {
+	collector := NewCollector("")
+	collector.TaskDropped(async.CombinatorThrottle)
+	collector.TaskDropped(async.CombinatorThrottle)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(collector.dropped.WithLabelValues(async.CombinatorThrottle)))
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
 )
 
@@ -21,9 +22,10 @@ type batch struct {
 	ctx       context.Context
 	lastID    uint64                            // The last id for result matching
 	pending   []batchEntry                      // The pending entries to the batch
-	batchTask Task                              // The current batch task
-	batch     chan []batchEntry                 // The current batch channel to execute
+	batchTask *task                             // The current batch task
+	batch     chan []batchEntry                 // The channel of the entries of the current batch task
 	process   func([]interface{}) []interface{} // The function which will be executed to process the items of the NewBatch
+	opts      []TaskOption                      // The options of the batch tasks
 }
 
 // Batch represents a batch where one can append to the batch and process it as a whole.
@@ -33,13 +35,14 @@ type Batch interface {
 	Reduce()
 }
 
-// NewBatch creates a new batch
-func NewBatch(ctx context.Context, process func([]interface{}) []interface{}) Batch {
+// NewBatch creates a new batch. The options configure the task of every batch, for example
+// WithMetrics, which records every batch from its reduction until it is processed.
+func NewBatch(ctx context.Context, process func([]interface{}) []interface{}, opts ...TaskOption) Batch {
 	return &batch{
 		ctx:     ctx,
 		pending: []batchEntry{},
-		batch:   make(chan []batchEntry),
 		process: process,
+		opts:    opts,
 	}
 }
 
@@ -57,15 +60,27 @@ func (b *batch) Append(payload interface{}) Task {
 		b.batchTask = b.createBatchTask()
 	}
 
-	// Batch task will need to continue with this one
-	t := b.batchTask.ContinueWith(b.ctx, func(batchResult interface{}, _ error) (interface{}, error) {
+	// Batch task will need to continue with this one, unless it was cancelled
+	t := b.batchTask.ContinueWith(b.ctx, func(batchResult interface{}, err error) (interface{}, error) {
+		if err != nil {
+			return nil, err
+		}
+
 		if res, ok := batchResult.(map[uint64]interface{}); ok {
 			return res[id], nil
 		}
 
 		actualType := fmt.Sprintf("%T", batchResult)
 		return nil, errors.New("Invalid batch type, got: " + actualType)
-	})
+	}, NotOnCancelled, ExecuteSynchronously).(*task)
+
+	// The entry is a member of the batch until processed. Its span covers its wait in the batch,
+	// and the span of the batch links to it.
+	t.setOwner(b.batchTask)
+	t.startSpan(b.ctx, nil)
+	if span, batchSpan := t.getSpan(), b.batchTask.getSpan(); span != nil && batchSpan != nil {
+		batchSpan.AddLink(span)
+	}
 
 	// Add to the task queue
 	b.pending = append(b.pending, batchEntry{
@@ -89,13 +104,23 @@ func (b *batch) Reduce() {
 	}
 
 	// Prepare the batch
-	batch := append([]batchEntry{}, b.pending...)
+	batch := b.pending
+	b.pending = []batchEntry{}
+
+	// Record the batch, which has waited since its first entry was appended
+	m := newMeter(b.batchTask)
+	startedAt := now()
+	m.started(startedAt.Sub(batch[0].task.Timing().QueuedAt))
+	m.whenDone(b.batchTask, func(state State, _ Timing) {
+		m.finished(state, now().Sub(startedAt))
+	})
 
 	// Run the current batch
 	b.batch <- batch
+	b.batchTask.Run(b.ctx)
 
-	// Swap the batch
-	b.batchTask = b.createBatchTask()
+	// The next batch task is created along with its first entry
+	b.batchTask = nil
 }
 
 // Size returns the length of the pending queue
@@ -105,11 +130,15 @@ func (b *batch) Size() int {
 	return len(b.pending)
 }
 
-// createBatchTask creates a task for the batch. Triggering this task will trigger the whole batch.
-func (b *batch) createBatchTask() Task {
-	return Invoke(b.ctx, func(context.Context) (interface{}, error) {
-		// block here until a batch is ordered to be processed
-		batch := <-b.batch
+// createBatchTask creates a task for the batch, which is run once the batch is reduced. The span
+// of the task is started right away, so the entries appended to the batch can be linked. The task
+// is cancelled if the context of the batch is done before.
+func (b *batch) createBatchTask() *task {
+	entries := make(chan []batchEntry, 1)
+	b.batch = entries
+
+	t := newTask(func(context.Context) (interface{}, error) {
+		batch := <-entries
 		m := map[uint64]interface{}{}
 
 		// prepare the input for the batch reduce call
@@ -127,5 +156,37 @@ func (b *batch) createBatchTask() Task {
 
 		// return the map of associations
 		return m, nil
+	}, append([]TaskOption{withCombinator(CombinatorBatch), withPending(func() []*task {
+		return b.pendingTasks(entries)
+	})}, b.opts...)...)
+
+	// Cancel the batch along with its entries if the context is done, and log it
+	stop := context.AfterFunc(b.ctx, func() {
+		t.CancelWithCause(fmt.Errorf("batch stopped: %w", b.ctx.Err()))
+	})
+	t.onDone(func() {
+		stop()
+		if t.State() == IsCancelled {
+			t.log(b.ctx, slog.LevelWarn, "batch cancelled", "error", t.outcome.err)
+		}
 	})
+
+	t.startSpan(b.ctx, nil)
+	return t
+}
+
+// pendingTasks returns the tasks of the entries pending in the batch task with the specified
+// channel of entries, if it is the current one.
+func (b *batch) pendingTasks(entries chan []batchEntry) []*task {
+	b.RLock()
+	defer b.RUnlock()
+	if b.batch != entries {
+		return nil
+	}
+
+	tasks := make([]*task, 0, len(b.pending))
+	for _, entry := range b.pending {
+		tasks = append(tasks, entry.task.(*task))
+	}
+	return tasks
 }
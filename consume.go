@@ -8,13 +8,14 @@ import (
 	"runtime"
 )
 
-// Consume runs the tasks with a specific max concurrency
-func Consume(ctx context.Context, concurrency int, tasks chan Task) Task {
+// Consume runs the tasks with a specific max concurrency. The options configure the task
+// of the consumer, for example WithMetrics.
+func Consume(ctx context.Context, concurrency int, tasks chan Task, opts ...TaskOption) Task {
 	if concurrency <= 0 {
 		concurrency = runtime.NumCPU()
 	}
 
-	return Invoke(ctx, func(taskCtx context.Context) (interface{}, error) {
+	return newCombinator(CombinatorConsume, func(taskCtx context.Context, m meter) (interface{}, error) {
 		workers := make(chan int, concurrency)
 		concurrentTasks := make([]Task, concurrency)
 		// generate worker IDs
@@ -44,13 +45,13 @@ func Consume(ctx context.Context, concurrency int, tasks chan Task) Task {
 						return nil, nil
 					}
 					concurrentTasks[workerID] = t
-					t.Run(taskCtx).ContinueWith(taskCtx,
+					m.run(taskCtx, t).ContinueWith(taskCtx,
 						func(interface{}, error) (interface{}, error) {
 							workers <- workerID
 							return nil, nil
-						})
+						}, ExecuteSynchronously)
 				}
 			}
 		}
-	})
+	}, opts).Run(ctx)
 }
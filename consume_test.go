@@ -92,7 +92,7 @@ func TestProcessTaskPool_SadPath(t *testing.T) {
 	for _, test := range tests {
 		m := test
 		taskChan := make(chan Task)
-		ctx, _ := context.WithTimeout(context.Background(), m.timeOut*time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeOut*time.Millisecond)
 		go func() {
 			for i := 0; i < m.taskCount; i++ {
 				taskChan <- NewTask(func(context.Context) (interface{}, error) {
@@ -104,5 +104,7 @@ func TestProcessTaskPool_SadPath(t *testing.T) {
 		p := Consume(ctx, m.concurrency, taskChan)
 		_, err := p.Outcome()
 		assert.NotNil(t, err, m.desc)
+		assert.Equal(t, IsCancelled, p.State(), m.desc)
+		cancel()
 	}
 }
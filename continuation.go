@@ -0,0 +1,130 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// ContinuationOptions specifies the behaviour of a continuation, similar to the
+// TaskContinuationOptions of .NET. Options can be combined with a bitwise OR.
+type ContinuationOptions byte
+
+// Various continuation options
+const (
+	NotOnRanToCompletion ContinuationOptions = 1 << iota // NotOnRanToCompletion skips the continuation if the task has succeeded
+	NotOnFaulted                                         // NotOnFaulted skips the continuation if the task has failed
+	NotOnCancelled                                       // NotOnCancelled skips the continuation if the task was cancelled
+	ExecuteSynchronously                                 // ExecuteSynchronously runs the continuation from the goroutine which completes the task
+
+	OnlyOnRanToCompletion = NotOnFaulted | NotOnCancelled         // OnlyOnRanToCompletion runs the continuation only if the task has succeeded
+	OnlyOnFaulted         = NotOnRanToCompletion | NotOnCancelled // OnlyOnFaulted runs the continuation only if the task has failed
+	OnlyOnCancelled       = NotOnRanToCompletion | NotOnFaulted   // OnlyOnCancelled runs the continuation only if the task was cancelled
+)
+
+// accepts returns whether the continuation should run for the specified final state.
+func (o ContinuationOptions) accepts(state State) bool {
+	switch state {
+	case IsCancelled:
+		return o&NotOnCancelled == 0
+	case IsFaulted:
+		return o&NotOnFaulted == 0
+	default:
+		return o&NotOnRanToCompletion == 0
+	}
+}
+
+// ContinueWith proceeds with the next task once the current one is finished. The continuation
+// is started by the current task and no goroutine is spawned until then. If the continuation is
+// skipped because of its options, it is cancelled, carrying over the outcome and hence the cause
+// of the current task if that one was cancelled as well.
+func (t *task) ContinueWith(ctx context.Context, nextAction func(interface{}, error) (interface{}, error), opts ...ContinuationOptions) Task {
+	var options ContinuationOptions
+	for _, opt := range opts {
+		options |= opt
+	}
+
+	return t.chain(ctx, options, false, nextAction)
+}
+
+// Then proceeds with the next action once the current task has succeeded. If the current task
+// has failed or was cancelled, the next action is skipped and the returned task ends up in the
+// same state with the same outcome.
+func (t *task) Then(ctx context.Context, nextAction func(interface{}) (interface{}, error)) Task {
+	return t.chain(ctx, OnlyOnRanToCompletion, true, func(result interface{}, _ error) (interface{}, error) {
+		return nextAction(result)
+	})
+}
+
+// Catch proceeds with the next action once the current task has failed, allowing to recover
+// from the error with a value. If the current task has succeeded or was cancelled, the next
+// action is skipped and the returned task ends up in the same state with the same outcome.
+func (t *task) Catch(ctx context.Context, nextAction func(error) (interface{}, error)) Task {
+	return t.chain(ctx, OnlyOnFaulted, true, func(_ interface{}, err error) (interface{}, error) {
+		return nextAction(err)
+	})
+}
+
+// Finally executes the action once the current task is done, regardless of its outcome. The
+// returned task ends up in the same state with the same outcome as the current task, unless
+// the action panics.
+func (t *task) Finally(action func(interface{}, error)) Task {
+	next := t.newContinuation(nil)
+	t.onDone(func() {
+		next.schedule(context.Background(), func(context.Context) {
+			defer func() {
+				if r := recover(); r != nil {
+					err := &PanicError{Value: r, Stack: debug.Stack()}
+					next.logPanic(context.Background(), err)
+					next.settle(IsFaulted, outcome{err: err})
+				}
+			}()
+
+			action(t.outcome.result, t.outcome.err)
+			next.settle(t.State(), t.outcome)
+		})
+	})
+	return next
+}
+
+// chain creates a continuation which runs the next action once the current task is done, if the
+// options accept the final state of the task. Otherwise, the continuation is settled right away,
+// either with the state and the outcome of the current task or as cancelled. The continuation is
+// cancelled if its context is done while waiting for the current task.
+func (t *task) chain(ctx context.Context, options ContinuationOptions, passThrough bool, nextAction func(interface{}, error) (interface{}, error)) Task {
+	next := t.newContinuation(func(context.Context) (interface{}, error) {
+		return nextAction(t.outcome.result, t.outcome.err)
+	})
+
+	stop := context.AfterFunc(ctx, func() {
+		next.settle(IsCancelled, outcome{err: ctx.Err()})
+	})
+	t.onDone(func() {
+		stop()
+		state := t.State()
+		switch {
+		case options.accepts(state) && options&ExecuteSynchronously != 0:
+			next.run(ctx)
+		case options.accepts(state):
+			next.schedule(ctx, next.run)
+		case passThrough || state == IsCancelled:
+			next.settle(state, t.outcome)
+		default:
+			next.settle(IsCancelled, outcome{err: &CancelledError{Cause: fmt.Errorf("continuation skipped, antecedent is %v", state)}})
+		}
+	})
+	return next
+}
+
+// newContinuation creates a continuation of the current task, which inherits its name, labels,
+// tracer, logger, executor and the combinator it is a member of. The span of the continuation
+// is a child of the span of the current task.
+func (t *task) newContinuation(action Work) *task {
+	next := newTask(action, WithName(t.name), WithLabels(t.labels), WithTracer(t.tracer), WithLogger(t.logger),
+		WithExecutor(t.executor), withParent(t), withOwner(t.getOwner()))
+	next.external = true
+	return next
+}
@@ -0,0 +1,248 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThen(t *testing.T) {
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return 1, nil
+	}).Then(context.Background(), func(result interface{}) (interface{}, error) {
+		return result.(int) + 1, nil
+	})
+
+	result, err := task.Outcome()
+	assert.Equal(t, 2, result)
+	assert.Nil(t, err)
+	assert.Equal(t, IsCompleted, task.State())
+}
+
+func TestThenShortCircuitsError(t *testing.T) {
+	someErr := errors.New("some error")
+	called := false
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return nil, someErr
+	}).Then(context.Background(), func(result interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	}).Then(context.Background(), func(result interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+
+	_, err := task.Outcome()
+	assert.Equal(t, someErr, err)
+	assert.Equal(t, IsFaulted, task.State())
+	assert.False(t, called)
+}
+
+func TestThenCancelled(t *testing.T) {
+	first := NewTask(func(context.Context) (interface{}, error) {
+		return 1, nil
+	})
+	next := first.Then(context.Background(), func(result interface{}) (interface{}, error) {
+		return result, nil
+	})
+
+	first.Cancel()
+	_, err := next.Outcome()
+	assert.Equal(t, ErrCancelled, err)
+	assert.Equal(t, IsCancelled, next.State())
+}
+
+func TestThenRunIsIgnored(t *testing.T) {
+	p := NewPromise()
+	next := p.Task().Then(context.Background(), func(result interface{}) (interface{}, error) {
+		return result.(int) * 2, nil
+	})
+
+	next.Run(context.Background())
+	assert.Equal(t, IsCreated, next.State())
+
+	p.Resolve(21)
+	result, _ := next.Outcome()
+	assert.Equal(t, 42, result)
+}
+
+func TestCatch(t *testing.T) {
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return nil, errors.New("some error")
+	}).Catch(context.Background(), func(err error) (interface{}, error) {
+		return "recovered from " + err.Error(), nil
+	})
+
+	result, err := task.Outcome()
+	assert.Equal(t, "recovered from some error", result)
+	assert.Nil(t, err)
+}
+
+func TestCatchSkippedOnSuccess(t *testing.T) {
+	called := false
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return 1, nil
+	}).Catch(context.Background(), func(err error) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+
+	result, err := task.Outcome()
+	assert.Equal(t, 1, result)
+	assert.Nil(t, err)
+	assert.False(t, called)
+}
+
+func TestFinally(t *testing.T) {
+	someErr := errors.New("some error")
+	finalized := make(chan error, 1)
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return 1, someErr
+	}).Finally(func(_ interface{}, err error) {
+		finalized <- err
+	})
+
+	result, err := task.Outcome()
+	assert.Equal(t, 1, result)
+	assert.Equal(t, someErr, err)
+	assert.Equal(t, someErr, <-finalized)
+}
+
+func TestFinallyCancelled(t *testing.T) {
+	first := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		time.Sleep(100 * time.Millisecond)
+		return 1, nil
+	})
+
+	called := make(chan struct{}, 1)
+	next := first.Finally(func(interface{}, error) {
+		called <- struct{}{}
+	})
+
+	time.Sleep(10 * time.Millisecond)
+	first.Cancel()
+
+	_, err := next.Outcome()
+	assert.Equal(t, ErrCancelled, err)
+	assert.Equal(t, IsCancelled, next.State())
+	assert.Len(t, called, 1)
+}
+
+func TestFinallyPanic(t *testing.T) {
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return 1, nil
+	}).Finally(func(interface{}, error) {
+		panic("test")
+	})
+
+	_, err := task.Outcome()
+	assert.IsType(t, &PanicError{}, err)
+	assert.Equal(t, IsFaulted, task.State())
+}
+
+func ExampleTask_Then() {
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return nil, errors.New("some error")
+	}).Then(context.Background(), func(result interface{}) (interface{}, error) {
+		return "never called", nil
+	}).Catch(context.Background(), func(err error) (interface{}, error) {
+		return "fallback", nil
+	}).Finally(func(result interface{}, err error) {
+		fmt.Println("done with", result)
+	})
+
+	fmt.Println(task.Outcome())
+
+	// Output:
+	// done with fallback
+	// fallback <nil>
+}
+
+func TestContinueWithOnlyOnRanToCompletion(t *testing.T) {
+	called := false
+	next := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return nil, errors.New("some error")
+	}).ContinueWith(context.Background(), func(interface{}, error) (interface{}, error) {
+		called = true
+		return nil, nil
+	}, OnlyOnRanToCompletion)
+
+	_, err := next.Outcome()
+	assert.True(t, errors.Is(err, ErrCancelled))
+	assert.EqualError(t, err, "context canceled: continuation skipped, antecedent is faulted")
+	assert.Equal(t, IsCancelled, next.State())
+	assert.False(t, called)
+}
+
+func TestContinueWithNotOnCancelled(t *testing.T) {
+	first := NewTask(func(context.Context) (interface{}, error) {
+		return 1, nil
+	})
+
+	called := false
+	next := first.ContinueWith(context.Background(), func(interface{}, error) (interface{}, error) {
+		called = true
+		return nil, nil
+	}, NotOnCancelled)
+
+	first.Cancel()
+	_, err := next.Outcome()
+	assert.Equal(t, ErrCancelled, err)
+	assert.Equal(t, IsCancelled, next.State())
+	assert.False(t, called)
+}
+
+func TestContinueWithOnlyOnFaulted(t *testing.T) {
+	next := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return nil, errors.New("some error")
+	}).ContinueWith(context.Background(), func(_ interface{}, err error) (interface{}, error) {
+		return "handled " + err.Error(), nil
+	}, OnlyOnFaulted)
+
+	result, err := next.Outcome()
+	assert.Equal(t, "handled some error", result)
+	assert.Nil(t, err)
+}
+
+func TestContinueWithExecuteSynchronously(t *testing.T) {
+	p := NewPromise()
+	next := p.Task().ContinueWith(context.Background(), func(result interface{}, _ error) (interface{}, error) {
+		return result.(int) + 1, nil
+	}, ExecuteSynchronously)
+
+	// The continuation is done by the time the promise is resolved
+	p.Resolve(1)
+	assert.Equal(t, IsCompleted, next.State())
+
+	result, _ := next.Outcome()
+	assert.Equal(t, 2, result)
+}
+
+func TestContinueWithContextDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// The continuation of a promise which is never resolved gives up once its context is done
+	p := NewPromise()
+	next := p.Task().ContinueWith(ctx, func(interface{}, error) (interface{}, error) {
+		return nil, nil
+	})
+
+	select {
+	case <-next.Done():
+	case <-time.After(time.Second):
+		assert.Fail(t, "the continuation should be cancelled with its context")
+		return
+	}
+
+	_, err := next.Outcome()
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, IsCancelled, next.State())
+}
@@ -9,6 +9,10 @@
 //
 // Fork/join pattern - running a bunch of work and waiting for everything to finish.
 //
+// WhenAny pattern - racing a bunch of work and waiting for the first one to finish.
+//
+// Hedge pattern - launching duplicate attempts of slow work and keeping the first successful one.
+//
 // Throttling pattern - throttling task execution on a specified rate.
 //
 // Spread pattern - spreading tasks across time.
@@ -18,5 +22,19 @@
 // Repeat pattern - repeating a certain task at a specified interval.
 //
 // Batch pattern - batching many tasks into a single one with individual continuations.
+//
+// Type-safe tasks using generics, provided by the typed sub-package.
+//
+// Metrics of the tasks run by the combinators, with expvar and Prometheus (asyncprom module) adapters.
+//
+// Registry of the tasks in flight, served as HTML or JSON by an http.Handler such as /debug/async.
+//
+// Profile labels on the goroutines of the tasks, to slice the CPU and goroutine profiles by task.
+//
+// Structured logging of panics, dropped tasks, cancelled batches and rate limiter waits, compatible with log/slog.
+//
+// Pluggable executors scheduling the tasks, such as a fixed pool of workers bounding the number of goroutines.
+//
+// Worker pool pattern - running the submitted tasks on a long-lived, resizable pool of workers with a bounded queue.
 
 package async
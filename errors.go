@@ -0,0 +1,86 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PanicError represents the error of a task whose work has panicked. It carries the
+// recovered value along with the stack trace of the panicking goroutine.
+type PanicError struct {
+	Value interface{} // The value recovered from the panic
+	Stack []byte      // The stack trace at the time of the panic
+}
+
+// Error returns the error message.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic recovered: %v", e.Value)
+}
+
+// Unwrap returns the recovered value if it is an error.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// CancelledError represents the error of a task which was cancelled with a cause.
+type CancelledError struct {
+	Cause error // The reason of the cancellation
+}
+
+// Error returns the error message.
+func (e *CancelledError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrCancelled, e.Cause)
+}
+
+// Is returns whether the target is ErrCancelled.
+func (e *CancelledError) Is(target error) bool {
+	return target == ErrCancelled
+}
+
+// Unwrap returns the cause of the cancellation.
+func (e *CancelledError) Unwrap() error {
+	return e.Cause
+}
+
+// TaskError represents the error of a single task within a set of tasks.
+type TaskError struct {
+	Index int   // The index of the task within the set
+	Err   error // The error of the task
+}
+
+// Error returns the error message.
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("task %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the task.
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError aggregates the errors of a set of tasks, ordered by task index.
+type MultiError []*TaskError
+
+// Error returns the error message.
+func (e MultiError) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d task(s) failed: %s", len(e), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the errors of the tasks, so errors.Is and errors.As match any of them.
+func (e MultiError) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
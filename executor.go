@@ -0,0 +1,185 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Executor schedules the execution of the tasks and of their continuations. A task is executed on
+// a single goroutine of its executor, from start to finish.
+type Executor interface {
+	Execute(fn func())
+}
+
+// ExecutorFunc is an adapter to use an ordinary function as an Executor.
+type ExecutorFunc func(fn func())
+
+// Execute calls the function with the function to execute.
+func (f ExecutorFunc) Execute(fn func()) {
+	f(fn)
+}
+
+// UnboundedExecutor executes every function on a new goroutine. It is the default executor.
+type UnboundedExecutor struct{}
+
+// Execute executes the function on a new goroutine.
+func (UnboundedExecutor) Execute(fn func()) {
+	go fn()
+}
+
+// CallerRunsExecutor executes every function synchronously on the calling goroutine, so a task is
+// done once it is run, which makes it handy for tests.
+type CallerRunsExecutor struct{}
+
+// Execute executes the function on the calling goroutine.
+func (CallerRunsExecutor) Execute(fn func()) {
+	fn()
+}
+
+// FixedExecutor executes the functions on a fixed number of worker goroutines, bounding the number
+// of goroutines executing the tasks. The functions waiting for a worker are queued without bound,
+// so Execute never blocks. A task never waits for a worker held by a task which may be waiting for
+// it: the task of a combinator runs on its own goroutine and only its members are executed by the
+// workers, while a task run from the work of a task executed by the same executor, directly or
+// through a combinator, runs on its own goroutine.
+type FixedExecutor struct {
+	sync.Mutex
+	ready   *sync.Cond // Signals the workers that a function was queued or the executor stopped
+	queue   []func()   // The functions waiting for a worker
+	stopped bool       // Whether the executor was stopped
+	workers sync.WaitGroup
+}
+
+// NewFixedExecutor creates an executor with the specified number of workers, which must be
+// stopped once no longer used.
+func NewFixedExecutor(workers int) *FixedExecutor {
+	e := &FixedExecutor{}
+	e.ready = sync.NewCond(&e.Mutex)
+	e.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go e.work()
+	}
+	return e
+}
+
+// Execute queues the function to be executed by a worker. Once the executor is stopped, the
+// function is executed on a new goroutine instead, so no task is ever lost.
+func (e *FixedExecutor) Execute(fn func()) {
+	e.Lock()
+	defer e.Unlock()
+	if e.stopped {
+		go fn()
+		return
+	}
+
+	e.queue = append(e.queue, fn)
+	e.ready.Signal()
+}
+
+// Stop stops the workers once they have executed the queued functions, and waits for them to exit.
+func (e *FixedExecutor) Stop() {
+	e.Lock()
+	e.stopped = true
+	e.ready.Broadcast()
+	e.Unlock()
+	e.workers.Wait()
+}
+
+// work executes the queued functions until the executor is stopped and the queue is empty.
+func (e *FixedExecutor) work() {
+	defer e.workers.Done()
+	for {
+		e.Lock()
+		for len(e.queue) == 0 && !e.stopped {
+			e.ready.Wait()
+		}
+		if len(e.queue) == 0 {
+			e.Unlock()
+			return
+		}
+
+		fn := e.queue[0]
+		e.queue[0] = nil
+		e.queue = e.queue[1:]
+		e.Unlock()
+
+		fn()
+	}
+}
+
+// workerKey is the key of the fixed executors whose workers are held by the tasks whose work runs,
+// directly or not, the task run with the context.
+type workerKey struct{}
+
+var globalExecutor atomic.Pointer[Executor] // The global executor, if any
+
+// SetExecutor sets the executor of every task which has no executor of its own. A nil executor
+// restores the default UnboundedExecutor.
+func SetExecutor(executor Executor) {
+	globalExecutor.Store(&executor)
+}
+
+// WithExecutor sets the executor of the task, which is inherited by its continuations. The executor
+// of a combinator is the executor of the tasks it runs which have no executor of their own, while
+// the task of the combinator itself runs on its own goroutine.
+func WithExecutor(executor Executor) TaskOption {
+	return func(t *task) {
+		t.executor = executor
+	}
+}
+
+// getExecutor returns the executor of the task, if any, otherwise the one of the combinator it is a
+// member of, the global one or the default one.
+func (t *task) getExecutor() Executor {
+	if t.executor != nil {
+		return t.executor
+	}
+
+	if owner := t.getOwner(); owner != nil && owner.executor != nil {
+		return owner.executor
+	}
+
+	if executor := globalExecutor.Load(); executor != nil && *executor != nil {
+		return *executor
+	}
+	return UnboundedExecutor{}
+}
+
+// schedule executes the function, which runs the task with the specified context, on the executor
+// of the task. The task is executed on its own goroutine instead if a worker of the same fixed
+// executor is held by a task whose work runs it, directly or not, as that task may be waiting for
+// it.
+func (t *task) schedule(ctx context.Context, run func(context.Context)) {
+	executor := t.getExecutor()
+	fixed, ok := executor.(*FixedExecutor)
+	switch {
+	case !ok:
+		executor.Execute(func() {
+			run(ctx)
+		})
+	case holdsWorker(ctx, fixed):
+		go run(ctx)
+	default:
+		held, _ := ctx.Value(workerKey{}).([]*FixedExecutor)
+		ctx = context.WithValue(ctx, workerKey{}, append(held[:len(held):len(held)], fixed))
+		fixed.Execute(func() {
+			run(ctx)
+		})
+	}
+}
+
+// holdsWorker returns whether a worker of the executor is held by a task whose work runs, directly
+// or not, the task run with the context.
+func holdsWorker(ctx context.Context, executor *FixedExecutor) bool {
+	held, _ := ctx.Value(workerKey{}).([]*FixedExecutor)
+	for _, e := range held {
+		if e == executor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingExecutor executes the functions on new goroutines and counts them
+func countingExecutor(count *int32) Executor {
+	return ExecutorFunc(func(fn func()) {
+		atomic.AddInt32(count, 1)
+		go fn()
+	})
+}
+
+func TestCallerRunsExecutor(t *testing.T) {
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return 1, nil
+	}, WithExecutor(CallerRunsExecutor{}))
+
+	// The task is done once Invoke returns
+	assert.Equal(t, IsCompleted, task.State())
+
+	next := task.ContinueWith(context.Background(), func(result interface{}, err error) (interface{}, error) {
+		return result.(int) + 1, err
+	})
+	assert.Equal(t, IsCompleted, next.State())
+	v, _ := next.Outcome()
+	assert.Equal(t, 2, v)
+}
+
+func TestFixedExecutor(t *testing.T) {
+	executor := NewFixedExecutor(3)
+	defer executor.Stop()
+
+	var running, maxRunning int32
+	tasks := make([]Task, 0, 6)
+	for i := 0; i < 6; i++ {
+		tasks = append(tasks, NewTask(func(context.Context) (interface{}, error) {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil, nil
+		}))
+	}
+
+	// The fork runs on its own goroutine, so its tasks run on the three workers
+	_, err := ForkJoinResults(context.Background(), tasks, WithExecutor(executor)).Outcome()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&maxRunning))
+}
+
+func TestFixedExecutorCombinator(t *testing.T) {
+	executor := NewFixedExecutor(1)
+	defer executor.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// The fork does not hold the only worker while waiting for its tasks
+	tasks := NewTasks(
+		func(context.Context) (interface{}, error) { return 1, nil },
+		func(context.Context) (interface{}, error) { return 2, nil },
+	)
+	_, err := ForkJoin(context.Background(), tasks, WithExecutor(executor)).Await(ctx)
+	assert.NoError(t, err)
+}
+
+func TestFixedExecutorNestedWait(t *testing.T) {
+	executor := NewFixedExecutor(2)
+	defer executor.Stop()
+	SetExecutor(executor)
+	defer SetExecutor(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Every worker is held by a task waiting for the tasks started by its work, which run on their
+	// own goroutines rather than waiting for a worker
+	tasks := make([]Task, 0, 2)
+	for i := 0; i < 2; i++ {
+		tasks = append(tasks, Invoke(context.Background(), func(ctx context.Context) (interface{}, error) {
+			if _, err := Invoke(ctx, func(context.Context) (interface{}, error) {
+				return nil, nil
+			}).Outcome(); err != nil {
+				return nil, err
+			}
+
+			return ForkJoin(ctx, NewTasks(func(context.Context) (interface{}, error) {
+				return nil, nil
+			}), WithExecutor(executor)).Outcome()
+		}))
+	}
+
+	for _, task := range tasks {
+		_, err := task.Await(ctx)
+		assert.NoError(t, err)
+	}
+}
+
+func TestFixedExecutorStop(t *testing.T) {
+	executor := NewFixedExecutor(1)
+	executor.Stop()
+
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return 1, nil
+	}, WithExecutor(executor))
+
+	v, err := task.Outcome()
+	assert.Equal(t, 1, v)
+	assert.NoError(t, err)
+}
+
+func TestExecutorCancel(t *testing.T) {
+	executor := NewFixedExecutor(1)
+	defer executor.Stop()
+
+	release := make(chan struct{})
+	task := Invoke(context.Background(), func(ctx context.Context) (interface{}, error) {
+		<-release
+		return nil, nil
+	}, WithExecutor(executor))
+
+	// The cancelled task is done right away while its work still holds the worker
+	task.Cancel()
+	_, err := task.Outcome()
+	assert.Equal(t, ErrCancelled, err)
+	close(release)
+}
+
+func TestExecutorInherited(t *testing.T) {
+	var count int32
+	task := Invoke(context.Background(), func(ctx context.Context) (interface{}, error) {
+		return Invoke(ctx, func(context.Context) (interface{}, error) {
+			return 1, nil
+		}).Outcome()
+	}, WithExecutor(countingExecutor(&count)))
+
+	_, _ = task.ContinueWith(context.Background(), func(interface{}, error) (interface{}, error) {
+		return nil, nil
+	}).Outcome()
+
+	// The task and its continuation, but not the task started by its work
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+}
+
+func TestSetExecutor(t *testing.T) {
+	var count int32
+	SetExecutor(countingExecutor(&count))
+	defer SetExecutor(nil)
+
+	tasks := make(chan Task, 2)
+	tasks <- NewTask(func(context.Context) (interface{}, error) { return 1, nil })
+	tasks <- NewTask(func(context.Context) (interface{}, error) { return 2, nil })
+	close(tasks)
+	_, _ = Consume(context.Background(), 1, tasks).Outcome()
+
+	// The two tasks, as the consumer runs on its own goroutine
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+}
+
+func ExampleNewFixedExecutor() {
+	executor := NewFixedExecutor(4)
+	defer executor.Stop()
+
+	tasks := NewTasks(
+		func(context.Context) (interface{}, error) { return 1, nil },
+		func(context.Context) (interface{}, error) { return 2, nil },
+		func(context.Context) (interface{}, error) { return 3, nil },
+	)
+
+	results, err := InvokeAllResults(context.Background(), 2, tasks, WithExecutor(executor)).Outcome()
+	fmt.Println(results, err)
+
+	// Output:
+	// [1 2 3] <nil>
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"expvar"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets of the histograms of the queue
+// wait and of the run time published by ExpvarMetrics.
+var DefaultBuckets = []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10, 60}
+
+// ExpvarMetrics is an expvar.Var recording the metrics of the combinators, to be published with
+// expvar.Publish. Every combinator is a map holding the counts of the started, dropped and in-flight
+// tasks, the count of finished tasks by final state, and the histograms of the queue wait and of
+// the run time.
+type ExpvarMetrics struct {
+	sync.Mutex
+	vars expvar.Map // The map of the combinators
+}
+
+// NewExpvarMetrics creates the metrics.
+func NewExpvarMetrics() *ExpvarMetrics {
+	m := new(ExpvarMetrics)
+	m.vars.Init()
+	return m
+}
+
+// Get returns the map of the combinator, or nil if it has not recorded any task.
+func (m *ExpvarMetrics) Get(combinator string) *expvar.Map {
+	vars, _ := m.vars.Get(combinator).(*expvar.Map)
+	return vars
+}
+
+// String returns the metrics as a JSON object, by combinator.
+func (m *ExpvarMetrics) String() string {
+	return m.vars.String()
+}
+
+// TaskStarted records a task started by the combinator after waiting in its queue.
+func (m *ExpvarMetrics) TaskStarted(combinator string, queueWait time.Duration) {
+	vars := m.combinator(combinator)
+	vars.Add("started", 1)
+	vars.Add("in_flight", 1)
+	vars.Get("queue_wait_seconds").(*histogram).observe(queueWait)
+}
+
+// TaskFinished records the final state and the run time of a task started by the combinator.
+func (m *ExpvarMetrics) TaskFinished(combinator string, state State, runTime time.Duration) {
+	vars := m.combinator(combinator)
+	vars.Add(state.String(), 1)
+	vars.Add("in_flight", -1)
+	vars.Get("run_time_seconds").(*histogram).observe(runTime)
+}
+
+// TaskDropped records a task cancelled by the combinator without ever being started.
+func (m *ExpvarMetrics) TaskDropped(combinator string) {
+	m.combinator(combinator).Add("dropped", 1)
+}
+
+// combinator returns the map of the combinator, creating it on first use.
+func (m *ExpvarMetrics) combinator(name string) *expvar.Map {
+	if vars := m.Get(name); vars != nil {
+		return vars
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if vars := m.Get(name); vars != nil {
+		return vars
+	}
+
+	vars := new(expvar.Map).Init()
+	vars.Set("queue_wait_seconds", newHistogram(DefaultBuckets))
+	vars.Set("run_time_seconds", newHistogram(DefaultBuckets))
+	m.vars.Set(name, vars)
+	return vars
+}
+
+// histogram is an expvar.Var counting durations into cumulative buckets
+type histogram struct {
+	bounds []float64 // The upper bounds of the buckets, in seconds
+	counts []uint64  // The counts of the buckets, the last one being unbounded
+	sum    int64     // The sum of the durations, in nanoseconds
+}
+
+// newHistogram creates a histogram with the specified upper bounds.
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{
+		bounds: append([]float64{}, bounds...),
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// observe counts a duration.
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(h.bounds) && d.Seconds() > h.bounds[i] {
+		i++
+	}
+
+	atomic.AddUint64(&h.counts[i], 1)
+	atomic.AddInt64(&h.sum, int64(d))
+}
+
+// String returns the histogram as a JSON object, with the cumulative count of every bucket.
+func (h *histogram) String() string {
+	var b strings.Builder
+	var count uint64
+	b.WriteString(`{"buckets": {`)
+	for i := range h.counts {
+		count += atomic.LoadUint64(&h.counts[i])
+		bound := "+Inf"
+		if i < len(h.bounds) {
+			bound = strconv.FormatFloat(h.bounds[i], 'g', -1, 64)
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%q: %d", bound, count)
+	}
+
+	sum := time.Duration(atomic.LoadInt64(&h.sum)).Seconds()
+	fmt.Fprintf(&b, `}, "count": %d, "sum": %s}`, count, strconv.FormatFloat(sum, 'g', -1, 64))
+	return b.String()
+}
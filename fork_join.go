@@ -3,19 +3,68 @@
 
 package async
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
-// ForkJoin executes input task in parallel and waits for ALL outcomes before returning.
-func ForkJoin(ctx context.Context, tasks []Task) Task {
-	return Invoke(ctx, func(context.Context) (interface{}, error) {
+// ForkJoin executes input task in parallel and waits for ALL outcomes before returning. The
+// options configure the task of the fork, for example WithExecutor.
+func ForkJoin(ctx context.Context, tasks []Task, opts ...TaskOption) Task {
+	return newCombinator(CombinatorForkJoin, func(taskCtx context.Context, m meter) (interface{}, error) {
 		for _, task := range tasks {
-			_ = task.Run(ctx)
+			_ = m.run(taskCtx, task)
 		}
 		WaitAll(tasks)
 		return nil, nil
+	}, opts).Run(ctx)
+}
+
+// ForkJoinResults executes input task in parallel and waits for ALL outcomes before returning.
+// The result of the returned task is the ordered slice of the results of the input tasks and
+// its error is a MultiError of every failed task, or nil if none of them failed.
+func ForkJoinResults(ctx context.Context, tasks []Task, opts ...TaskOption) Task {
+	return ForkJoin(ctx, tasks, opts...).ContinueWith(ctx, func(interface{}, error) (interface{}, error) {
+		return collect(tasks)
 	})
 }
 
+// ForkJoinFailFast executes input task in parallel and waits for ALL outcomes, unless one of
+// them fails. The first error cancels the context shared by the tasks along with the tasks
+// which are still pending, and completes the returned task with that error right away.
+// Otherwise, the result of the returned task is the ordered slice of the results.
+func ForkJoinFailFast(ctx context.Context, tasks []Task, opts ...TaskOption) Task {
+	return newCombinator(CombinatorForkJoin, func(taskCtx context.Context, m meter) (interface{}, error) {
+		groupCtx, cancel := context.WithCancel(taskCtx)
+		defer cancel()
+
+		errs := make(chan error, len(tasks))
+		for _, task := range tasks {
+			m.run(groupCtx, task).ContinueWith(ctx, func(_ interface{}, err error) (interface{}, error) {
+				errs <- err
+				return nil, nil
+			}, ExecuteSynchronously)
+		}
+
+		for range tasks {
+			select {
+			case <-ctx.Done():
+				CancelAllWithCause(tasks, ctx.Err())
+				return nil, ctx.Err()
+
+			case err := <-errs:
+				if err != nil {
+					cancel()
+					CancelAllWithCause(tasks, fmt.Errorf("sibling task failed: %w", err))
+					return nil, err
+				}
+			}
+		}
+
+		return collect(tasks)
+	}, opts).Run(ctx)
+}
+
 // WaitAll waits for all tasks to finish.
 func WaitAll(tasks []Task) {
 	for _, task := range tasks {
@@ -31,3 +80,28 @@ func CancelAll(tasks []Task) {
 		task.Cancel()
 	}
 }
+
+// CancelAllWithCause cancels all specified tasks, recording the cause in their error.
+func CancelAllWithCause(tasks []Task, cause error) {
+	for _, task := range tasks {
+		task.CancelWithCause(cause)
+	}
+}
+
+// collect waits for all tasks to finish and gathers their results and errors.
+func collect(tasks []Task) (interface{}, error) {
+	var errs MultiError
+	results := make([]interface{}, len(tasks))
+	for i, task := range tasks {
+		result, err := task.Outcome()
+		results[i] = result
+		if err != nil {
+			errs = append(errs, &TaskError{Index: i, Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return results, errs
+	}
+	return results, nil
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -38,6 +39,81 @@ func TestForkJoin(t *testing.T) {
 	assert.Nil(t, error3)
 }
 
+func TestForkJoinResults(t *testing.T) {
+	someErr := errors.New("some error")
+	tasks := NewTasks(
+		func(context.Context) (interface{}, error) { return 1, nil },
+		func(context.Context) (interface{}, error) { return nil, someErr },
+		func(context.Context) (interface{}, error) { return 3, nil },
+	)
+
+	result, err := ForkJoinResults(context.Background(), tasks).Outcome()
+	assert.Equal(t, []interface{}{1, nil, 3}, result)
+	assert.True(t, errors.Is(err, someErr))
+
+	multiErr, ok := err.(MultiError)
+	assert.True(t, ok)
+	assert.Len(t, multiErr, 1)
+	assert.Equal(t, 1, multiErr[0].Index)
+	assert.Equal(t, "1 task(s) failed: task 1: some error", err.Error())
+}
+
+func TestForkJoinResultsNoError(t *testing.T) {
+	tasks := NewTasks(
+		func(context.Context) (interface{}, error) { return 1, nil },
+		func(context.Context) (interface{}, error) { return 2, nil },
+	)
+
+	result, err := ForkJoinResults(context.Background(), tasks).Outcome()
+	assert.Equal(t, []interface{}{1, 2}, result)
+	assert.Nil(t, err)
+}
+
+func TestForkJoinFailFast(t *testing.T) {
+	someErr := errors.New("some error")
+	siblingCancelled := make(chan struct{})
+	slow := NewTask(func(ctx context.Context) (interface{}, error) {
+		select {
+		case <-ctx.Done():
+			close(siblingCancelled)
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+			return 1, nil
+		}
+	})
+	failing := NewTask(func(context.Context) (interface{}, error) {
+		time.Sleep(10 * time.Millisecond)
+		return nil, someErr
+	})
+
+	t0 := time.Now()
+	result, err := ForkJoinFailFast(context.Background(), []Task{slow, failing}).Outcome()
+	assert.Nil(t, result)
+	assert.Equal(t, someErr, err)
+	assert.True(t, time.Since(t0) < 500*time.Millisecond)
+
+	_, slowErr := slow.Outcome()
+	assert.Error(t, slowErr)
+	assert.Equal(t, IsCancelled, slow.State())
+
+	select {
+	case <-siblingCancelled:
+	case <-time.After(time.Second):
+		assert.Fail(t, "the context of the sibling was not cancelled")
+	}
+}
+
+func TestForkJoinFailFastNoError(t *testing.T) {
+	tasks := NewTasks(
+		func(context.Context) (interface{}, error) { return 1, nil },
+		func(context.Context) (interface{}, error) { return 2, nil },
+	)
+
+	result, err := ForkJoinFailFast(context.Background(), tasks).Outcome()
+	assert.Equal(t, []interface{}{1, 2}, result)
+	assert.Nil(t, err)
+}
+
 func ExampleForkJoin() {
 	first := NewTask(func(context.Context) (interface{}, error) {
 		return 1, nil
@@ -1,8 +1,14 @@
 module github.com/grab/async
 
-go 1.12
+go 1.21
 
 require (
 	github.com/stretchr/testify v1.3.0
 	golang.org/x/time v0.0.0-20191024005414-555d28b269f0
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/objx v0.1.0 // indirect
+)
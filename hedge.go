@@ -0,0 +1,135 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// HedgeStats represents the statistics of the hedged calls, which can be used to tune the delays.
+type HedgeStats struct {
+	Calls    uint64   // The number of hedged calls
+	Attempts uint64   // The number of attempts launched, including the first attempt of every call
+	Failures uint64   // The number of calls for which every attempt has failed
+	Wins     []uint64 // The number of calls won by each attempt, indexed by attempt
+}
+
+// Hedger invokes work with hedged requests and keeps statistics about them.
+type Hedger struct {
+	calls    uint64          // The number of hedged calls
+	attempts uint64          // The number of attempts launched
+	failures uint64          // The number of calls which have failed
+	wins     []uint64        // The number of calls won by each attempt
+	delays   []time.Duration // The delays before launching each duplicate attempt
+}
+
+// NewHedger creates a new hedger. Each delay is the time to wait after the previous attempt was
+// launched before launching a duplicate one, hence the number of delays is the maximum number of
+// duplicate attempts.
+func NewHedger(delays ...time.Duration) *Hedger {
+	return &Hedger{
+		wins:   make([]uint64, len(delays)+1),
+		delays: delays,
+	}
+}
+
+// Hedge invokes the work with hedged requests, see Hedger.Hedge.
+func Hedge(ctx context.Context, work Work, delays ...time.Duration) Task {
+	return NewHedger(delays...).Hedge(ctx, work)
+}
+
+// Hedge invokes the work and launches duplicate attempts after the configured delays if no result
+// has arrived yet. If every attempt in flight has failed, the next one is launched right away. The
+// returned task completes with the first successful outcome and the remaining attempts are
+// cancelled. If all attempts fail, the task completes with the error of the last one. Cancelling
+// the returned task cancels the attempts in flight and launches no further ones. The options
+// configure the returned task, for example WithExecutor, which schedules the attempts on the
+// executor, or WithMetrics.
+func (h *Hedger) Hedge(ctx context.Context, work Work, opts ...TaskOption) Task {
+	atomic.AddUint64(&h.calls, 1)
+	return newCombinator(CombinatorHedge, func(taskCtx context.Context, m meter) (interface{}, error) {
+		hedgeCtx, cancel := context.WithCancel(taskCtx)
+		defer cancel()
+
+		type attemptOutcome struct {
+			index int
+			outcome
+		}
+
+		outcomes := make(chan attemptOutcome, len(h.delays)+1)
+		attempts := make([]Task, 0, len(h.delays)+1)
+		launch := func() {
+			index := len(attempts)
+			atomic.AddUint64(&h.attempts, 1)
+			attempts = append(attempts, m.run(hedgeCtx, NewTask(work)))
+			attempts[index].ContinueWith(taskCtx, func(result interface{}, err error) (interface{}, error) {
+				outcomes <- attemptOutcome{index: index, outcome: outcome{result: result, err: err}}
+				return nil, nil
+			}, ExecuteSynchronously)
+		}
+
+		launch()
+		failed := 0
+		for {
+			var timer *time.Timer
+			var hedge <-chan time.Time
+			if len(attempts) <= len(h.delays) {
+				timer = time.NewTimer(h.delays[len(attempts)-1])
+				hedge = timer.C
+			}
+
+			select {
+			case <-taskCtx.Done():
+				stopTimer(timer)
+				CancelAllWithCause(attempts, taskCtx.Err())
+				return nil, taskCtx.Err()
+
+			case <-hedge:
+				launch()
+
+			case o := <-outcomes:
+				stopTimer(timer)
+				if o.err == nil {
+					atomic.AddUint64(&h.wins[o.index], 1)
+					CancelAllWithCause(attempts, fmt.Errorf("hedged attempt %d won", o.index))
+					return o.result, nil
+				}
+
+				// Launch the next attempt right away if everything in flight has failed
+				if failed++; failed == len(attempts) {
+					if len(attempts) > len(h.delays) {
+						atomic.AddUint64(&h.failures, 1)
+						return nil, o.err
+					}
+					launch()
+				}
+			}
+		}
+	}, opts).Run(ctx)
+}
+
+// Stats returns a snapshot of the hedging statistics.
+func (h *Hedger) Stats() HedgeStats {
+	wins := make([]uint64, len(h.wins))
+	for i := range h.wins {
+		wins[i] = atomic.LoadUint64(&h.wins[i])
+	}
+
+	return HedgeStats{
+		Calls:    atomic.LoadUint64(&h.calls),
+		Attempts: atomic.LoadUint64(&h.attempts),
+		Failures: atomic.LoadUint64(&h.failures),
+		Wins:     wins,
+	}
+}
+
+// stopTimer stops the timer, if any.
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
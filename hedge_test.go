@@ -0,0 +1,127 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHedge(t *testing.T) {
+	var calls int32
+	var slowCancelled int32
+	h := NewHedger(10*time.Millisecond, 10*time.Millisecond)
+	task := h.Hedge(context.Background(), func(ctx context.Context) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-ctx.Done()
+			atomic.StoreInt32(&slowCancelled, 1)
+			return nil, ctx.Err()
+		}
+		return "fast", nil
+	})
+
+	result, err := task.Outcome()
+	assert.Equal(t, "fast", result)
+	assert.Nil(t, err)
+
+	stats := h.Stats()
+	assert.Equal(t, uint64(1), stats.Calls)
+	assert.Equal(t, uint64(2), stats.Attempts)
+	assert.Equal(t, []uint64{0, 1, 0}, stats.Wins)
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&slowCancelled))
+}
+
+func TestHedgeNoDuplicate(t *testing.T) {
+	h := NewHedger(100 * time.Millisecond)
+	result, err := h.Hedge(context.Background(), func(context.Context) (interface{}, error) {
+		return 1, nil
+	}).Outcome()
+
+	assert.Equal(t, 1, result)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), h.Stats().Attempts)
+}
+
+func TestHedgeFailures(t *testing.T) {
+	var calls int32
+	h := NewHedger(time.Second, time.Second)
+
+	t0 := time.Now()
+	_, err := h.Hedge(context.Background(), func(context.Context) (interface{}, error) {
+		return nil, fmt.Errorf("error %d", atomic.AddInt32(&calls, 1))
+	}).Outcome()
+
+	assert.EqualError(t, err, "error 3")
+	assert.True(t, time.Since(t0) < 500*time.Millisecond)
+
+	stats := h.Stats()
+	assert.Equal(t, uint64(3), stats.Attempts)
+	assert.Equal(t, uint64(1), stats.Failures)
+}
+
+func TestHedgeContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := Hedge(ctx, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, errors.New("too late")
+	}, 5*time.Millisecond).Outcome()
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestHedgeCancel(t *testing.T) {
+	h := NewHedger(5*time.Millisecond, 5*time.Millisecond, 5*time.Millisecond)
+	started := make(chan struct{}, 4)
+	released := make(chan struct{}, 4)
+
+	task := h.Hedge(context.Background(), func(ctx context.Context) (interface{}, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		released <- struct{}{}
+		return nil, ctx.Err()
+	})
+	<-started
+	task.Cancel()
+	_, err := task.Outcome()
+	assert.Equal(t, ErrCancelled, err)
+
+	// The attempt in flight is cancelled and no further attempt is launched
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		assert.Fail(t, "the attempt in flight should be cancelled")
+	}
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, uint64(1), h.Stats().Attempts)
+	assert.Len(t, started, 0)
+}
+
+func TestHedgeTaskOptions(t *testing.T) {
+	var count int32
+	metrics := newMemoryMetrics()
+	h := NewHedger(time.Millisecond)
+	task := h.Hedge(context.Background(), func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("some error")
+	}, WithName("hedge"), WithExecutor(countingExecutor(&count)), WithMetrics(metrics))
+	_, err := task.Outcome()
+
+	// The attempts are scheduled on the executor and recorded as members
+	assert.EqualError(t, err, "some error")
+	assert.Equal(t, "hedge", task.Name())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+	metrics.Lock()
+	defer metrics.Unlock()
+	assert.Equal(t, 2, metrics.started[CombinatorHedge])
+	assert.Equal(t, 2, metrics.finished[CombinatorHedge][IsFaulted])
+}
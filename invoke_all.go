@@ -5,23 +5,37 @@ package async
 
 import "context"
 
-// InvokeAll runs the tasks with a specific max concurrency
-func InvokeAll(ctx context.Context, concurrency int, tasks []Task) Task {
-	if concurrency == 0 {
-		return ForkJoin(ctx, tasks)
-	}
+// InvokeAll runs the tasks with a specific max concurrency, or all at once if the concurrency
+// is zero. The options configure the task of the invoker, for example WithMetrics.
+func InvokeAll(ctx context.Context, concurrency int, tasks []Task, opts ...TaskOption) Task {
+	return newCombinator(CombinatorInvokeAll, func(taskCtx context.Context, m meter) (interface{}, error) {
+		if concurrency == 0 {
+			for _, task := range tasks {
+				m.run(taskCtx, task)
+			}
+			WaitAll(tasks)
+			return nil, nil
+		}
 
-	return Invoke(ctx, func(context.Context) (interface{}, error) {
 		sem := make(chan struct{}, concurrency)
 		for _, task := range tasks {
 			sem <- struct{}{}
-			task.Run(ctx).ContinueWith(ctx,
+			m.run(taskCtx, task).ContinueWith(ctx,
 				func(interface{}, error) (interface{}, error) {
 					<-sem
 					return nil, nil
-				})
+				}, ExecuteSynchronously)
 		}
 		WaitAll(tasks)
 		return nil, nil
+	}, opts).Run(ctx)
+}
+
+// InvokeAllResults runs the tasks with a specific max concurrency. The result of the returned
+// task is the ordered slice of the results of the input tasks and its error is a MultiError of
+// every failed task, or nil if none of them failed.
+func InvokeAllResults(ctx context.Context, concurrency int, tasks []Task, opts ...TaskOption) Task {
+	return InvokeAll(ctx, concurrency, tasks, opts...).ContinueWith(ctx, func(interface{}, error) (interface{}, error) {
+		return collect(tasks)
 	})
 }
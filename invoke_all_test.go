@@ -56,6 +56,23 @@ func TestInvokeAllWithZeroConcurrency(t *testing.T) {
 	assert.Equal(t, []int{1, 1, 1, 1, 1, 1}, res)
 }
 
+func TestInvokeAllResults(t *testing.T) {
+	works := make([]Work, 6)
+	for i := range works {
+		j := i
+		works[j] = func(context.Context) (interface{}, error) {
+			if j%3 == 0 {
+				return nil, fmt.Errorf("error %d", j)
+			}
+			return j, nil
+		}
+	}
+
+	result, err := InvokeAllResults(context.Background(), 2, NewTasks(works...)).Outcome()
+	assert.Equal(t, []interface{}{nil, 1, 2, nil, 4, 5}, result)
+	assert.EqualError(t, err, "2 task(s) failed: task 0: error 0; task 3: error 3")
+}
+
 func ExampleInvokeAll() {
 	resChan := make(chan int, 6)
 	works := make([]Work, 6, 6)
@@ -0,0 +1,71 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Logger logs the notable events of the tasks and the combinators, such as recovered panics,
+// dropped tasks, cancelled batches and rate limiter waits. It is implemented by *slog.Logger.
+type Logger interface {
+	Log(ctx context.Context, level slog.Level, msg string, args ...interface{})
+}
+
+var globalLogger atomic.Pointer[Logger] // The global logger, if any
+
+// SetLogger sets the logger of every task which has no logger of its own. A nil logger, which is
+// the default, disables the logging.
+func SetLogger(logger Logger) {
+	globalLogger.Store(&logger)
+}
+
+// WithLogger sets the logger of the task. The logger of a combinator is also the logger of the
+// tasks it runs which have no logger of their own.
+func WithLogger(logger Logger) TaskOption {
+	return func(t *task) {
+		t.logger = logger
+	}
+}
+
+// getLogger returns the logger of the task, if any, otherwise the one of the combinator it is a
+// member of, if any, or the global one.
+func (t *task) getLogger() Logger {
+	if t.logger != nil {
+		return t.logger
+	}
+
+	if owner := t.getOwner(); owner != nil && owner.logger != nil {
+		return owner.logger
+	}
+
+	if logger := globalLogger.Load(); logger != nil {
+		return *logger
+	}
+	return nil
+}
+
+// log logs a message along with the identifier, the name and the combinator of the task.
+func (t *task) log(ctx context.Context, level slog.Level, msg string, args ...interface{}) {
+	logger := t.getLogger()
+	if logger == nil {
+		return
+	}
+
+	attrs := []interface{}{ProfileLabelTaskID, t.id}
+	if t.name != "" {
+		attrs = append(attrs, ProfileLabelTaskName, t.name)
+	}
+	if t.combinator != "" {
+		attrs = append(attrs, ProfileLabelCombinator, t.combinator)
+	}
+	logger.Log(ctx, level, msg, append(attrs, args...)...)
+}
+
+// logPanic logs a panic of the work of the task.
+func (t *task) logPanic(ctx context.Context, err *PanicError) {
+	t.log(ctx, slog.LevelError, "task panicked", "panic", err.Value, "stack", string(err.Stack))
+}
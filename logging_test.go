@@ -0,0 +1,178 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordHandler is a slog handler sending the records to a channel
+type recordHandler struct {
+	records chan slog.Record
+}
+
+func newRecordLogger() (*slog.Logger, chan slog.Record) {
+	records := make(chan slog.Record, 100)
+	return slog.New(&recordHandler{records: records}), records
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler            { return h }
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records <- r
+	return nil
+}
+
+// attrs returns the attributes of the record
+func attrs(r slog.Record) map[string]interface{} {
+	m := map[string]interface{}{}
+	r.Attrs(func(a slog.Attr) bool {
+		m[a.Key] = a.Value.Any()
+		return true
+	})
+	return m
+}
+
+func TestLoggerPanic(t *testing.T) {
+	logger, records := newRecordLogger()
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		panic("boom")
+	}, WithName("exploding"), WithLogger(logger))
+
+	_, err := task.Outcome()
+	assert.Error(t, err)
+
+	r := <-records
+	assert.Equal(t, slog.LevelError, r.Level)
+	assert.Equal(t, "task panicked", r.Message)
+	assert.Equal(t, task.ID(), attrs(r)[ProfileLabelTaskID])
+	assert.Equal(t, "exploding", attrs(r)[ProfileLabelTaskName])
+	assert.Equal(t, "boom", attrs(r)["panic"])
+}
+
+func TestLoggerCombinator(t *testing.T) {
+	logger, records := newRecordLogger()
+	tasks := make(chan Task, 1)
+	tasks <- NewTask(func(context.Context) (interface{}, error) {
+		panic("boom")
+	})
+	close(tasks)
+
+	consumer := Consume(context.Background(), 1, tasks, WithLogger(logger))
+	_, _ = consumer.Outcome()
+
+	// The members of a combinator use its logger
+	r := <-records
+	assert.Equal(t, "task panicked", r.Message)
+	assert.NotEqual(t, consumer.ID(), attrs(r)[ProfileLabelTaskID])
+}
+
+func TestLoggerRepeatPanic(t *testing.T) {
+	logger, records := newRecordLogger()
+	task := Repeat(context.Background(), time.Millisecond, func(context.Context) (interface{}, error) {
+		panic("boom")
+	}, WithLogger(logger))
+	defer task.Cancel()
+
+	r := <-records
+	assert.Equal(t, slog.LevelError, r.Level)
+	assert.Equal(t, "action panicked", r.Message)
+	assert.Equal(t, CombinatorRepeat, attrs(r)[ProfileLabelCombinator])
+	assert.Equal(t, "boom", attrs(r)["panic"])
+}
+
+func TestLoggerThrottle(t *testing.T) {
+	logger, records := newRecordLogger()
+	tasks := NewTasks(func(context.Context) (interface{}, error) {
+		return nil, nil
+	})
+
+	_, _ = Throttle(context.Background(), tasks, 1, time.Millisecond, WithLogger(logger)).Outcome()
+
+	r := <-records
+	assert.Equal(t, slog.LevelDebug, r.Level)
+	assert.Equal(t, "rate limiter waited", r.Message)
+	assert.Equal(t, tasks[0].ID(), attrs(r)["member_id"])
+}
+
+func TestLoggerDropped(t *testing.T) {
+	logger, records := newRecordLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tasks := NewTasks(func(context.Context) (interface{}, error) {
+		return nil, nil
+	})
+	Spread(ctx, time.Millisecond, tasks, WithLogger(logger))
+	WaitAll(tasks)
+
+	r := <-records
+	assert.Equal(t, slog.LevelWarn, r.Level)
+	assert.Equal(t, "task dropped", r.Message)
+	assert.Equal(t, tasks[0].ID(), attrs(r)["member_id"])
+	assert.True(t, errors.Is(attrs(r)["error"].(error), context.Canceled))
+}
+
+func TestLoggerBatchCancelled(t *testing.T) {
+	logger, records := newRecordLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	batch := NewBatch(ctx, func(input []interface{}) []interface{} {
+		return input
+	}, WithLogger(logger))
+
+	task := batch.Append(1)
+	cancel()
+	_, _ = task.Outcome()
+
+	r := <-records
+	assert.Equal(t, slog.LevelWarn, r.Level)
+	assert.Equal(t, "batch cancelled", r.Message)
+	assert.Equal(t, CombinatorBatch, attrs(r)[ProfileLabelCombinator])
+}
+
+func TestSetLogger(t *testing.T) {
+	logger, records := newRecordLogger()
+	SetLogger(logger)
+	defer SetLogger(nil)
+
+	_, _ = Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		panic("boom")
+	}).Outcome()
+
+	assert.Equal(t, "task panicked", (<-records).Message)
+}
+
+func ExampleWithLogger() {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "stack" || a.Key == ProfileLabelTaskID {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		panic("boom")
+	}, WithName("example"), WithLogger(logger))
+
+	_, err := task.Outcome()
+	fmt.Println(err)
+	fmt.Print(strings.TrimSpace(out.String()))
+
+	// Output:
+	// panic recovered: boom
+	// level=ERROR msg="task panicked" async.task.name=example panic=boom
+}
@@ -0,0 +1,185 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Names of the combinators, as reported to the metrics
+const (
+	CombinatorForkJoin  = "fork_join"
+	CombinatorConsume   = "consume"
+	CombinatorInvokeAll = "invoke_all"
+	CombinatorThrottle  = "throttle"
+	CombinatorSpread    = "spread"
+	CombinatorRepeat    = "repeat"
+	CombinatorBatch     = "batch"
+	CombinatorPool      = "worker_pool"
+	CombinatorWhenAny   = "when_any"
+	CombinatorHedge     = "hedge"
+)
+
+// Metrics records the tasks run by the combinators, such as Consume or InvokeAll. The number of
+// tasks in flight is the number of started tasks minus the number of finished ones. Metrics are
+// called synchronously and hence should return quickly. The ExpvarMetrics type and the asyncprom
+// package provide adapters for expvar and Prometheus.
+type Metrics interface {
+	// TaskStarted records a task started by the combinator after waiting in its queue.
+	TaskStarted(combinator string, queueWait time.Duration)
+
+	// TaskFinished records the final state and the run time of a task started by the combinator.
+	TaskFinished(combinator string, state State, runTime time.Duration)
+
+	// TaskDropped records a task cancelled or rejected by the combinator without ever being started.
+	TaskDropped(combinator string)
+}
+
+var globalMetrics atomic.Pointer[Metrics] // The global metrics, if any
+
+// SetMetrics sets the metrics of every combinator which has no metrics of its own. Nil metrics
+// disable the metrics.
+func SetMetrics(metrics Metrics) {
+	globalMetrics.Store(&metrics)
+}
+
+// WithMetrics sets the metrics of a combinator, which records the tasks run by the combinator.
+func WithMetrics(metrics Metrics) TaskOption {
+	return func(t *task) {
+		t.metrics = metrics
+	}
+}
+
+// getMetrics returns the metrics of the task, if any, or the global ones.
+func (t *task) getMetrics() Metrics {
+	if t.metrics != nil {
+		return t.metrics
+	}
+
+	if metrics := globalMetrics.Load(); metrics != nil {
+		return *metrics
+	}
+	return nil
+}
+
+// meter records the tasks run by a combinator, which become the members of the task of the
+// combinator. A meter without metrics records nothing.
+type meter struct {
+	combinator string  // The name of the combinator
+	owner      *task   // The task of the combinator
+	metrics    Metrics // The metrics to record to, if any
+}
+
+// newMeter creates the meter of a combinator, using the metrics of its task.
+func newMeter(t *task) meter {
+	return meter{combinator: t.combinator, owner: t, metrics: t.getMetrics()}
+}
+
+// withCombinator sets the name of the combinator whose task is being created.
+func withCombinator(combinator string) TaskOption {
+	return func(t *task) {
+		t.combinator = combinator
+	}
+}
+
+// newCombinator creates the task of a combinator, whose work is given the meter of the tasks
+// run by the combinator.
+func newCombinator(combinator string, work func(context.Context, meter) (interface{}, error), opts []TaskOption) *task {
+	t := newTask(nil, append([]TaskOption{withCombinator(combinator)}, opts...)...)
+	m := newMeter(t)
+	t.action = func(ctx context.Context) (interface{}, error) {
+		return work(ctx, m)
+	}
+	return t
+}
+
+// run runs the task as a member of the combinator, recording the time it has waited since its
+// creation and its final state.
+func (m meter) run(ctx context.Context, t Task) Task {
+	return m.track(t, func() {
+		t.Run(ctx)
+	})
+}
+
+// execute runs the task as a member of the combinator synchronously, on the calling goroutine
+// rather than on the executor of the task.
+func (m meter) execute(ctx context.Context, t *task) {
+	m.track(t, func() {
+		t.run(ctx)
+	})
+}
+
+// track starts the task as a member of the combinator and records it.
+func (m meter) track(t Task, start func()) Task {
+	if inner, ok := t.(*task); ok {
+		inner.setOwner(m.owner)
+	}
+
+	if m.metrics == nil {
+		start()
+		return t
+	}
+
+	m.started(now().Sub(t.Timing().QueuedAt))
+	start()
+	m.whenDone(t, func(state State, timing Timing) {
+		m.finished(state, timing.Duration)
+	})
+	return t
+}
+
+// started records a task started by the combinator.
+func (m meter) started(queueWait time.Duration) {
+	if m.metrics != nil {
+		m.metrics.TaskStarted(m.combinator, queueWait)
+	}
+}
+
+// finished records a task started by the combinator which is done.
+func (m meter) finished(state State, runTime time.Duration) {
+	if m.metrics != nil {
+		m.metrics.TaskFinished(m.combinator, state, runTime)
+	}
+}
+
+// dropped records and logs the tasks cancelled by the combinator without being started.
+func (m meter) dropped(ctx context.Context, cause error, tasks ...Task) {
+	for _, t := range tasks {
+		m.owner.log(ctx, slog.LevelWarn, "task dropped", "member_id", t.ID(), "error", cause)
+		if m.metrics != nil {
+			m.metrics.TaskDropped(m.combinator)
+		}
+	}
+}
+
+// whenDone calls the callback with the final state and timing of the task once it is done. The
+// callback is called before anyone waiting for the task is notified, unless the task is not
+// created by this package.
+func (m meter) whenDone(t Task, callback func(State, Timing)) {
+	if m.metrics == nil {
+		return
+	}
+
+	inner, ok := t.(*task)
+	if !ok {
+		go func() {
+			<-t.Done()
+			callback(t.State(), t.Timing())
+		}()
+		return
+	}
+
+	observer := ObserverFunc(func(e Event) {
+		if e.To != IsCreated && e.To != IsRunning {
+			callback(e.To, e.Timing)
+		}
+	})
+
+	if !inner.observe(observer) {
+		callback(inner.State(), inner.Timing())
+	}
+}
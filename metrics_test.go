@@ -0,0 +1,217 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryMetrics records the metrics in memory
+type memoryMetrics struct {
+	sync.Mutex
+	started     map[string]int
+	finished    map[string]map[State]int
+	dropped     map[string]int
+	inFlight    int
+	maxInFlight int
+	events      chan string
+}
+
+func newMemoryMetrics() *memoryMetrics {
+	return &memoryMetrics{
+		started:  map[string]int{},
+		finished: map[string]map[State]int{},
+		dropped:  map[string]int{},
+		events:   make(chan string, 100),
+	}
+}
+
+func (m *memoryMetrics) TaskStarted(combinator string, queueWait time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	m.started[combinator]++
+	m.inFlight++
+	if m.inFlight > m.maxInFlight {
+		m.maxInFlight = m.inFlight
+	}
+}
+
+func (m *memoryMetrics) TaskFinished(combinator string, state State, runTime time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	if m.finished[combinator] == nil {
+		m.finished[combinator] = map[State]int{}
+	}
+	m.finished[combinator][state]++
+	m.inFlight--
+	m.events <- state.String()
+}
+
+func (m *memoryMetrics) TaskDropped(combinator string) {
+	m.Lock()
+	defer m.Unlock()
+	m.dropped[combinator]++
+}
+
+func TestConsumeMetrics(t *testing.T) {
+	metrics := newMemoryMetrics()
+	tasks := make(chan Task, 4)
+	for i := 0; i < 4; i++ {
+		i := i
+		tasks <- NewTask(func(context.Context) (interface{}, error) {
+			time.Sleep(10 * time.Millisecond)
+			if i == 0 {
+				return nil, errors.New("some error")
+			}
+			return i, nil
+		})
+	}
+	close(tasks)
+
+	_, err := Consume(context.Background(), 2, tasks, WithMetrics(metrics)).Outcome()
+	assert.NoError(t, err)
+
+	metrics.Lock()
+	defer metrics.Unlock()
+	assert.Equal(t, 4, metrics.started[CombinatorConsume])
+	assert.Equal(t, 3, metrics.finished[CombinatorConsume][IsCompleted])
+	assert.Equal(t, 1, metrics.finished[CombinatorConsume][IsFaulted])
+	assert.Equal(t, 0, metrics.inFlight)
+	assert.Equal(t, 2, metrics.maxInFlight)
+}
+
+func TestInvokeAllMetrics(t *testing.T) {
+	metrics := newMemoryMetrics()
+	tasks := NewTasks(
+		func(context.Context) (interface{}, error) { return 1, nil },
+		func(context.Context) (interface{}, error) { return nil, errors.New("some error") },
+	)
+
+	_, err := InvokeAll(context.Background(), 0, tasks, WithMetrics(metrics)).Outcome()
+	assert.NoError(t, err)
+
+	metrics.Lock()
+	defer metrics.Unlock()
+	assert.Equal(t, 2, metrics.started[CombinatorInvokeAll])
+	assert.Equal(t, 1, metrics.finished[CombinatorInvokeAll][IsCompleted])
+	assert.Equal(t, 1, metrics.finished[CombinatorInvokeAll][IsFaulted])
+}
+
+func TestThrottleMetricsDropped(t *testing.T) {
+	metrics := newMemoryMetrics()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tasks := NewTasks(
+		func(context.Context) (interface{}, error) { return 1, nil },
+		func(context.Context) (interface{}, error) { return 2, nil },
+	)
+
+	Throttle(ctx, tasks, 1, time.Second, WithMetrics(metrics))
+	WaitAll(tasks)
+
+	metrics.Lock()
+	defer metrics.Unlock()
+	assert.Equal(t, 0, metrics.started[CombinatorThrottle])
+	assert.Equal(t, 2, metrics.dropped[CombinatorThrottle])
+}
+
+func TestRepeatMetrics(t *testing.T) {
+	metrics := newMemoryMetrics()
+	task := Repeat(context.Background(), time.Millisecond, func(context.Context) (interface{}, error) {
+		panic("test")
+	}, WithMetrics(metrics))
+	defer task.Cancel()
+
+	assert.Equal(t, "faulted", <-metrics.events)
+}
+
+func TestBatchMetrics(t *testing.T) {
+	metrics := newMemoryMetrics()
+	batch := NewBatch(context.Background(), func(input []interface{}) []interface{} {
+		return input
+	}, WithMetrics(metrics))
+
+	task := batch.Append(1)
+	batch.Reduce()
+
+	v, err := task.Outcome()
+	assert.Equal(t, 1, v)
+	assert.NoError(t, err)
+	assert.Equal(t, "completed", <-metrics.events)
+
+	metrics.Lock()
+	defer metrics.Unlock()
+	assert.Equal(t, 1, metrics.started[CombinatorBatch])
+}
+
+func TestSetMetrics(t *testing.T) {
+	metrics := newMemoryMetrics()
+	SetMetrics(metrics)
+	defer SetMetrics(nil)
+
+	_, _ = Spread(context.Background(), time.Millisecond, NewTasks(func(context.Context) (interface{}, error) {
+		return 1, nil
+	})).Outcome()
+
+	metrics.Lock()
+	defer metrics.Unlock()
+	assert.Equal(t, 1, metrics.finished[CombinatorSpread][IsCompleted])
+}
+
+func TestExpvarMetrics(t *testing.T) {
+	metrics := NewExpvarMetrics()
+	metrics.TaskStarted(CombinatorConsume, 2*time.Millisecond)
+	metrics.TaskStarted(CombinatorConsume, 2*time.Second)
+	metrics.TaskFinished(CombinatorConsume, IsFaulted, 20*time.Millisecond)
+	metrics.TaskDropped(CombinatorConsume)
+
+	var vars map[string]struct {
+		Started   int `json:"started"`
+		Faulted   int `json:"faulted"`
+		Dropped   int `json:"dropped"`
+		InFlight  int `json:"in_flight"`
+		QueueWait struct {
+			Buckets map[string]int `json:"buckets"`
+			Count   int            `json:"count"`
+			Sum     float64        `json:"sum"`
+		} `json:"queue_wait_seconds"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(metrics.String()), &vars))
+
+	consume := vars[CombinatorConsume]
+	assert.Equal(t, 2, consume.Started)
+	assert.Equal(t, 1, consume.Faulted)
+	assert.Equal(t, 1, consume.Dropped)
+	assert.Equal(t, 1, consume.InFlight)
+	assert.Equal(t, 2, consume.QueueWait.Count)
+	assert.Equal(t, 0, consume.QueueWait.Buckets["0.001"])
+	assert.Equal(t, 1, consume.QueueWait.Buckets["0.005"])
+	assert.Equal(t, 2, consume.QueueWait.Buckets["+Inf"])
+	assert.InDelta(t, 2.002, consume.QueueWait.Sum, 1e-9)
+}
+
+func ExampleWithMetrics() {
+	metrics := NewExpvarMetrics() // expvar.Publish("async", metrics) to serve them on /debug/vars
+	tasks := NewTasks(
+		func(context.Context) (interface{}, error) { return 1, nil },
+		func(context.Context) (interface{}, error) { return 2, nil },
+	)
+
+	_, _ = InvokeAll(context.Background(), 1, tasks, WithMetrics(metrics)).Outcome()
+
+	vars := metrics.Get(CombinatorInvokeAll)
+	fmt.Println(vars.Get("started"), vars.Get("completed"), vars.Get("in_flight"))
+
+	// Output:
+	// 2 2 0
+}
@@ -5,8 +5,8 @@
 package mocks
 
 import (
+	"github.com/grab/async"
 	"github.com/stretchr/testify/mock"
-	"gitlab.myteksi.net/grab-x/async"
 )
 
 // Partitioner is an autogenerated mock type for the Partitioner type
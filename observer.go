@@ -0,0 +1,125 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Event represents a state transition of a task. The creation of a task is reported as a
+// transition from and to the created state. The final transition of a task is reported before
+// its done channel is closed, so its outcome must be read from the Result and Err of the event:
+// calling Outcome, Await or WaitAll on the task from the observer would block forever.
+type Event struct {
+	Task   Task        // The task which has transitioned, not yet done when observed
+	From   State       // The previous state of the task
+	To     State       // The new state of the task
+	Timing Timing      // The timing of the task at the time of the transition
+	Result interface{} // The result of the task, once it is done
+	Err    error       // The error of the task, once it is done
+}
+
+// Observer observes the state transitions of tasks, for example to plug in logging, metrics or
+// tracing. Observers are called synchronously and hence should return quickly. They are notified
+// of the final transition of a task before anyone waiting for it, hence they must not wait for the
+// task themselves, see Event.
+type Observer interface {
+	Observe(Event)
+}
+
+// ObserverFunc is an adapter to use an ordinary function as an Observer.
+type ObserverFunc func(Event)
+
+// Observe calls the function with the event.
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+var (
+	observersLock sync.Mutex
+	observers     atomic.Pointer[[]*globalObserver] // The global observers, if any
+)
+
+// globalObserver wraps a global observer so the same observer can be registered more than once.
+type globalObserver struct {
+	Observer
+}
+
+// AddObserver registers an observer of every task and returns a function which unregisters it.
+func AddObserver(o Observer) (remove func()) {
+	registered := &globalObserver{Observer: o}
+
+	observersLock.Lock()
+	defer observersLock.Unlock()
+	updated := append(append([]*globalObserver{}, globalObservers()...), registered)
+	observers.Store(&updated)
+
+	return func() {
+		observersLock.Lock()
+		defer observersLock.Unlock()
+		current := globalObservers()
+		updated := make([]*globalObserver, 0, len(current))
+		for _, g := range current {
+			if g != registered {
+				updated = append(updated, g)
+			}
+		}
+		observers.Store(&updated)
+	}
+}
+
+// globalObservers returns the global observers.
+func globalObservers() []*globalObserver {
+	if current := observers.Load(); current != nil {
+		return *current
+	}
+	return nil
+}
+
+// WithObserver registers an observer of the task.
+func WithObserver(o Observer) TaskOption {
+	return func(t *task) {
+		t.observers = append(t.observers, o)
+	}
+}
+
+// notify reports the state transition of the task to the global observers and to the
+// observers of the task.
+func (t *task) notify(from, to State) {
+	global := globalObservers()
+
+	t.Lock()
+	local := t.observers
+	if len(global) == 0 && len(local) == 0 {
+		t.Unlock()
+		return
+	}
+
+	e := Event{Task: t, From: from, To: to, Timing: t.timing}
+	if to != IsCreated && to != IsRunning {
+		e.Result, e.Err = t.outcome.result, t.outcome.err
+	}
+	t.Unlock()
+
+	for _, o := range global {
+		o.Observe(e)
+	}
+	for _, o := range local {
+		o.Observe(e)
+	}
+}
+
+// observe registers an observer of a task which was already created. It returns false, without
+// registering the observer, if the task has already transitioned to its final state.
+func (t *task) observe(o Observer) bool {
+	t.Lock()
+	defer t.Unlock()
+	if state := t.State(); state != IsCreated && state != IsRunning {
+		return false
+	}
+
+	t.observers = append(t.observers[:len(t.observers):len(t.observers)], o)
+	return true
+}
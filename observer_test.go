@@ -0,0 +1,107 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records the events of the observed tasks
+type recorder struct {
+	sync.Mutex
+	events []Event
+}
+
+func (r *recorder) Observe(e Event) {
+	r.Lock()
+	defer r.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) transitions(task Task) []State {
+	r.Lock()
+	defer r.Unlock()
+	var states []State
+	for _, e := range r.events {
+		if e.Task.ID() == task.ID() {
+			states = append(states, e.To)
+		}
+	}
+	return states
+}
+
+func (r *recorder) last(task Task) Event {
+	r.Lock()
+	defer r.Unlock()
+	var last Event
+	for _, e := range r.events {
+		if e.Task.ID() == task.ID() {
+			last = e
+		}
+	}
+	return last
+}
+
+func TestObserver(t *testing.T) {
+	r := &recorder{}
+	task := Invoke(context.Background(), sleepyWork(10*time.Millisecond, 1, nil), WithObserver(r))
+	_, _ = task.Outcome()
+
+	assert.Equal(t, []State{IsCreated, IsRunning, IsCompleted}, r.transitions(task))
+
+	last := r.last(task)
+	assert.Equal(t, IsRunning, last.From)
+	assert.Equal(t, 1, last.Result)
+	assert.Nil(t, last.Err)
+	assert.True(t, last.Timing.Duration >= 10*time.Millisecond)
+}
+
+func TestObserverFaulted(t *testing.T) {
+	someErr := errors.New("some error")
+	r := &recorder{}
+	task := Invoke(context.Background(), sleepyWork(0, nil, someErr), WithObserver(r))
+	_, _ = task.Outcome()
+
+	assert.Equal(t, []State{IsCreated, IsRunning, IsFaulted}, r.transitions(task))
+	assert.Equal(t, someErr, r.last(task).Err)
+}
+
+func TestObserverGlobal(t *testing.T) {
+	r := &recorder{}
+	remove := AddObserver(r)
+
+	cancelled := NewTask(sleepyWork(0, 1, nil))
+	cancelled.Cancel()
+	assert.Equal(t, []State{IsCreated, IsCancelled}, r.transitions(cancelled))
+
+	remove()
+	removed := Invoke(context.Background(), sleepyWork(0, 1, nil))
+	_, _ = removed.Outcome()
+	assert.Empty(t, r.transitions(removed))
+}
+
+func ExampleAddObserver() {
+	remove := AddObserver(ObserverFunc(func(e Event) {
+		if e.Task.Name() == "example" {
+			fmt.Println(e.Task.Name(), e.From, "->", e.To)
+		}
+	}))
+	defer remove()
+
+	_, _ = Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return 1, nil
+	}, WithName("example")).Outcome()
+
+	// Output:
+	// example created -> created
+	// example created -> running
+	// example running -> completed
+}
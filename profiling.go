@@ -0,0 +1,38 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"runtime/pprof"
+	"strconv"
+)
+
+// Profile labels of the goroutines of the tasks, which allow slicing the CPU and goroutine profiles
+// by task. The labels of the task are added with the AttributeLabelPrefix prefix.
+const (
+	ProfileLabelTaskID     = "async.task.id"    // The identifier of the task
+	ProfileLabelTaskName   = "async.task.name"  // The name of the task, empty if none
+	ProfileLabelCombinator = "async.combinator" // The combinator running the task, empty if none
+)
+
+// profileLabels returns the profile labels of the goroutines of the task. The tasks run by a
+// combinator are labelled with the name of the combinator, as is the task of the combinator. The
+// name and the combinator are always labelled, even if empty, so a task started by the work of
+// another task does not inherit them.
+func (t *task) profileLabels() pprof.LabelSet {
+	combinator := t.combinator
+	if owner := t.getOwner(); combinator == "" && owner != nil {
+		combinator = owner.combinator
+	}
+
+	labels := make([]string, 0, 6+2*len(t.labels))
+	labels = append(labels,
+		ProfileLabelTaskID, strconv.FormatUint(t.id, 10),
+		ProfileLabelTaskName, t.name,
+		ProfileLabelCombinator, combinator)
+	for k, v := range t.labels {
+		labels = append(labels, AttributeLabelPrefix+k, v)
+	}
+	return pprof.Labels(labels...)
+}
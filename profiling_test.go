@@ -0,0 +1,81 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileLabels(t *testing.T) {
+	task := Invoke(context.Background(), func(ctx context.Context) (interface{}, error) {
+		labels := map[string]string{}
+		pprof.ForLabels(ctx, func(k, v string) bool {
+			labels[k] = v
+			return true
+		})
+		return labels, nil
+	}, WithName("fetch"), WithLabels(map[string]string{"team": "x"}))
+
+	labels, err := task.Outcome()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		ProfileLabelTaskID:     strconv.FormatUint(task.ID(), 10),
+		ProfileLabelTaskName:   "fetch",
+		ProfileLabelCombinator: "",
+		"async.label.team":     "x",
+	}, labels)
+}
+
+func TestProfileLabelsNotInherited(t *testing.T) {
+	task := Invoke(context.Background(), func(ctx context.Context) (interface{}, error) {
+		return Invoke(ctx, func(ctx context.Context) (interface{}, error) {
+			name, _ := pprof.Label(ctx, ProfileLabelTaskName)
+			return name, nil
+		}).Outcome()
+	}, WithName("outer"))
+
+	name, err := task.Outcome()
+	assert.NoError(t, err)
+	assert.Equal(t, "", name)
+}
+
+func TestProfileLabelsCombinator(t *testing.T) {
+	member := NewTask(func(ctx context.Context) (interface{}, error) {
+		combinator, _ := pprof.Label(ctx, ProfileLabelCombinator)
+		return combinator, nil
+	})
+
+	tasks := make(chan Task, 1)
+	tasks <- member
+	close(tasks)
+	_, _ = Consume(context.Background(), 1, tasks).Outcome()
+
+	combinator, err := member.Outcome()
+	assert.NoError(t, err)
+	assert.Equal(t, CombinatorConsume, combinator)
+}
+
+func TestProfileLabelsGoroutine(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		close(started)
+		<-release
+		return nil, nil
+	}, WithName("blocked"))
+	defer close(release)
+	<-started
+
+	var profile bytes.Buffer
+	assert.NoError(t, pprof.Lookup("goroutine").WriteTo(&profile, 1))
+	assert.True(t, strings.Contains(profile.String(), fmt.Sprintf(`"async.task.id":"%d"`, task.ID())))
+	assert.True(t, strings.Contains(profile.String(), `"async.task.name":"blocked"`))
+}
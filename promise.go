@@ -0,0 +1,59 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import "errors"
+
+// ErrNilRejection is the error of a promise which was rejected with a nil error, as a faulted task
+// always has an error.
+var ErrNilRejection = errors.New("promise rejected with a nil error")
+
+// Promise represents a task whose outcome is set manually by external code, for example
+// by the callback of a client library. The task of the promise does not execute any work
+// and running it has no effect, so it can be passed to any combinator.
+type Promise struct {
+	task *task
+}
+
+// NewPromise creates a new promise with a task in the created state.
+func NewPromise() *Promise {
+	t := newTask(nil)
+	t.external = true
+	return &Promise{
+		task: t,
+	}
+}
+
+// Task returns the task of the promise, which is done once the promise is resolved,
+// rejected or cancelled.
+func (p *Promise) Task() Task {
+	return p.task
+}
+
+// Resolve completes the task of the promise with the result. It has no effect if the
+// task is already done.
+func (p *Promise) Resolve(result interface{}) {
+	p.TryResolve(result)
+}
+
+// Reject faults the task of the promise with the error, or ErrNilRejection if the error is nil. It
+// has no effect if the task is already done.
+func (p *Promise) Reject(err error) {
+	p.TryReject(err)
+}
+
+// TryResolve completes the task of the promise with the result and returns whether
+// the task was completed by this call.
+func (p *Promise) TryResolve(result interface{}) bool {
+	return p.task.settle(IsCompleted, outcome{result: result})
+}
+
+// TryReject faults the task of the promise with the error, or ErrNilRejection if the error is nil,
+// and returns whether the task was completed by this call.
+func (p *Promise) TryReject(err error) bool {
+	if err == nil {
+		err = ErrNilRejection
+	}
+	return p.task.settle(IsFaulted, outcome{err: err})
+}
@@ -0,0 +1,91 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromiseResolve(t *testing.T) {
+	p := NewPromise()
+	assert.Equal(t, IsCreated, p.Task().State())
+
+	next := p.Task().ContinueWith(context.Background(), func(result interface{}, err error) (interface{}, error) {
+		return result.(int) + 1, err
+	})
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		p.Resolve(1)
+	}()
+
+	result, err := next.Outcome()
+	assert.Equal(t, 2, result)
+	assert.Nil(t, err)
+	assert.Equal(t, IsCompleted, p.Task().State())
+}
+
+func TestPromiseReject(t *testing.T) {
+	someErr := errors.New("some error")
+	p := NewPromise()
+	p.Reject(someErr)
+
+	_, err := p.Task().Outcome()
+	assert.Equal(t, someErr, err)
+	assert.Equal(t, IsFaulted, p.Task().State())
+	assert.False(t, p.TryResolve(1))
+}
+
+func TestPromiseRejectNil(t *testing.T) {
+	p := NewPromise()
+	assert.True(t, p.TryReject(nil))
+
+	_, err := p.Task().Catch(context.Background(), func(err error) (interface{}, error) {
+		return nil, err
+	}).Outcome()
+	assert.Equal(t, ErrNilRejection, err)
+	assert.Equal(t, IsFaulted, p.Task().State())
+}
+
+func TestPromiseForkJoin(t *testing.T) {
+	p := NewPromise()
+	other := NewTask(func(context.Context) (interface{}, error) {
+		p.Resolve("resolved")
+		return nil, nil
+	})
+
+	result, err := ForkJoinResults(context.Background(), []Task{p.Task(), other}).Outcome()
+	assert.Equal(t, []interface{}{"resolved", nil}, result)
+	assert.Nil(t, err)
+}
+
+func TestPromiseCancel(t *testing.T) {
+	p := NewPromise()
+	p.Task().Cancel()
+
+	assert.False(t, p.TryResolve(1))
+	assert.False(t, p.TryReject(errors.New("some error")))
+	assert.Equal(t, IsCancelled, p.Task().State())
+
+	_, err := p.Task().Outcome()
+	assert.Equal(t, ErrCancelled, err)
+}
+
+func ExamplePromise() {
+	p := NewPromise()
+	go func() {
+		p.Resolve("hello")
+	}()
+
+	fmt.Println(p.Task().Outcome())
+
+	// Output:
+	// hello <nil>
+}
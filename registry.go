@@ -0,0 +1,206 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TaskInfo describes a task tracked by a registry.
+type TaskInfo struct {
+	ID         uint64            `json:"id"`                   // The identifier of the task
+	Name       string            `json:"name,omitempty"`       // The name of the task, if any
+	Labels     map[string]string `json:"labels,omitempty"`     // The labels of the task, if any
+	State      string            `json:"state"`                // The current state of the task
+	Age        time.Duration     `json:"age_ns"`               // The time elapsed since the creation of the task
+	ParentID   uint64            `json:"parent_id,omitempty"`  // The identifier of the antecedent of a continuation
+	Combinator string            `json:"combinator,omitempty"` // The name of the combinator, for the task of a combinator
+	Members    []TaskInfo        `json:"members,omitempty"`    // The members of the combinator, running or pending
+}
+
+// Registry tracks the tasks which are not done yet, for debugging purposes. It is an observer of
+// the tasks, which is registered either globally or per task:
+//
+//	registry := async.NewRegistry()
+//	async.AddObserver(registry)
+//	http.Handle("/debug/async", registry)
+//
+// Tasks are tracked while running, so a task which is never run is never tracked. The members of
+// a combinator, such as the tasks in flight in a Consume pool, are listed under the task of the
+// combinator along with its pending members, such as the entries of a Batch which is not reduced
+// yet or the tasks queued in a WorkerPool. A batch is tracked as soon as it has pending entries.
+type Registry struct {
+	sync.Mutex
+	tasks map[uint64]*task // The tracked tasks, by identifier
+}
+
+// NewRegistry creates a new registry.
+func NewRegistry() *Registry {
+	return &Registry{tasks: make(map[uint64]*task)}
+}
+
+// Observe tracks a task from its start until it is done.
+func (r *Registry) Observe(e Event) {
+	t, ok := e.Task.(*task)
+	if !ok {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	switch e.To {
+	case IsCreated:
+		if t.pending != nil {
+			r.tasks[t.id] = t // The combinator has pending members before being run, such as a batch
+		}
+	case IsRunning:
+		r.tasks[t.id] = t
+	default:
+		delete(r.tasks, t.id)
+	}
+}
+
+// Tasks returns the tracked tasks, ordered by identifier. The members of a combinator, running or
+// pending, are listed under the task of the combinator rather than at the top level.
+func (r *Registry) Tasks() []TaskInfo {
+	r.Lock()
+	tasks := make([]*task, 0, len(r.tasks))
+	for _, t := range r.tasks {
+		tasks = append(tasks, t)
+	}
+	r.Unlock()
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].id < tasks[j].id
+	})
+
+	tracked := make(map[*task]bool, len(tasks))
+	for _, t := range tasks {
+		tracked[t] = true
+	}
+
+	members := make(map[*task][]*task)
+	roots := make([]*task, 0, len(tasks))
+	for _, t := range tasks {
+		if owner := t.getOwner(); owner != nil && tracked[owner] {
+			members[owner] = append(members[owner], t)
+			continue
+		}
+		roots = append(roots, t)
+	}
+
+	// The pending members are listed through their combinator, as they are not tracked
+	for _, t := range tasks {
+		if t.pending == nil {
+			continue
+		}
+		for _, member := range t.pending() {
+			if !tracked[member] {
+				members[t] = append(members[t], member)
+			}
+		}
+		sort.Slice(members[t], func(i, j int) bool {
+			return members[t][i].id < members[t][j].id
+		})
+	}
+
+	return describe(roots, members, now())
+}
+
+// ServeHTTP renders the tracked tasks as JSON if requested with the format=json query parameter
+// or an Accept header of application/json, and as HTML otherwise.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	tasks := r.Tasks()
+	if req.URL.Query().Get("format") == "json" || strings.Contains(req.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(tasks)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := registryTemplate.Execute(w, tasks); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// describe describes the tasks along with their members.
+func describe(tasks []*task, members map[*task][]*task, at time.Time) []TaskInfo {
+	infos := make([]TaskInfo, 0, len(tasks))
+	for _, t := range tasks {
+		info := TaskInfo{
+			ID:         t.id,
+			Name:       t.name,
+			Labels:     t.Labels(),
+			State:      t.State().String(),
+			Age:        at.Sub(t.Timing().QueuedAt),
+			Combinator: t.combinator,
+			Members:    describe(members[t], members, at),
+		}
+		if t.parent != nil {
+			info.ParentID = t.parent.id
+		}
+		if len(info.Labels) == 0 {
+			info.Labels = nil
+		}
+		if len(info.Members) == 0 {
+			info.Members = nil
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// withPending sets the function listing the members of the combinator whose task is being
+// created which are not running yet.
+func withPending(pending func() []*task) TaskOption {
+	return func(t *task) {
+		t.pending = pending
+	}
+}
+
+// withOwner sets the task of the combinator the task being created is a member of.
+func withOwner(owner *task) TaskOption {
+	return func(t *task) {
+		t.owner = owner
+	}
+}
+
+// setOwner sets the task of the combinator the task is a member of.
+func (t *task) setOwner(owner *task) {
+	t.Lock()
+	defer t.Unlock()
+	t.owner = owner
+}
+
+// getOwner returns the task of the combinator the task is a member of, if any.
+func (t *task) getOwner() *task {
+	t.Lock()
+	defer t.Unlock()
+	return t.owner
+}
+
+// registryTemplate renders the tracked tasks as an HTML page
+var registryTemplate = template.Must(template.New("registry").Parse(`<!DOCTYPE html>
+<html>
+<head><title>async tasks</title></head>
+<body>
+<h1>async tasks</h1>
+<table border="1" cellpadding="4">
+<tr><th>ID</th><th>Name</th><th>Combinator</th><th>State</th><th>Age</th><th>Parent</th><th>Labels</th></tr>
+{{template "tasks" .}}
+</table>
+</body>
+</html>
+{{define "tasks"}}{{range .}}<tr><td>{{.ID}}</td><td>{{.Name}}</td><td>{{.Combinator}}</td><td>{{.State}}</td><td>{{.Age}}</td><td>{{if .ParentID}}{{.ParentID}}{{end}}</td><td>{{range $k, $v := .Labels}}{{$k}}={{$v}} {{end}}</td></tr>
+{{if .Members}}<tr><td></td><td colspan="6"><table border="1" cellpadding="4">
+<tr><th>ID</th><th>Name</th><th>Combinator</th><th>State</th><th>Age</th><th>Parent</th><th>Labels</th></tr>
+{{template "tasks" .Members}}
+</table></td></tr>
+{{end}}{{end}}{{end}}`))
@@ -0,0 +1,178 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// find returns the tracked task with the specified identifier
+func find(tasks []TaskInfo, id uint64) (TaskInfo, bool) {
+	for _, t := range tasks {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return TaskInfo{}, false
+}
+
+// serveJSON requests the tasks tracked by the registry as JSON
+func serveJSON(t *testing.T, registry *Registry) []TaskInfo {
+	w := httptest.NewRecorder()
+	registry.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/async?format=json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var tasks []TaskInfo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tasks))
+	return tasks
+}
+
+func TestRegistryConsume(t *testing.T) {
+	registry := NewRegistry()
+	defer AddObserver(registry)()
+
+	release := make(chan struct{})
+	tasks := make(chan Task, 3)
+	for i := 0; i < 3; i++ {
+		tasks <- NewTask(func(context.Context) (interface{}, error) {
+			<-release
+			return nil, nil
+		}, WithName("member"))
+	}
+	close(tasks)
+
+	consumer := Consume(context.Background(), 2, tasks, WithName("consumer"))
+	time.Sleep(20 * time.Millisecond)
+
+	info, ok := find(serveJSON(t, registry), consumer.ID())
+	assert.True(t, ok)
+	assert.Equal(t, "consumer", info.Name)
+	assert.Equal(t, CombinatorConsume, info.Combinator)
+	assert.Equal(t, "running", info.State)
+	assert.True(t, info.Age > 0)
+
+	running := 0
+	for _, member := range info.Members {
+		if member.State == "running" {
+			assert.Equal(t, "member", member.Name)
+			running++
+		}
+	}
+	assert.Equal(t, 2, running)
+
+	close(release)
+	_, _ = consumer.Outcome()
+	_, ok = find(serveJSON(t, registry), consumer.ID())
+	assert.False(t, ok)
+}
+
+func TestRegistryBatch(t *testing.T) {
+	registry := NewRegistry()
+	defer AddObserver(registry)()
+
+	batch := NewBatch(context.Background(), func(input []interface{}) []interface{} {
+		return input
+	})
+
+	first := batch.Append(1)
+	second := batch.Append(2)
+
+	var batchInfo TaskInfo
+	for _, info := range registry.Tasks() {
+		if info.Combinator == CombinatorBatch && len(info.Members) > 0 {
+			batchInfo = info
+		}
+	}
+
+	assert.Len(t, batchInfo.Members, 2)
+	assert.Equal(t, first.ID(), batchInfo.Members[0].ID)
+	assert.Equal(t, second.ID(), batchInfo.Members[1].ID)
+	assert.Equal(t, batchInfo.ID, batchInfo.Members[0].ParentID)
+	assert.Equal(t, "created", batchInfo.Members[0].State)
+
+	batch.Reduce()
+	_, _ = second.Outcome()
+	_, ok := find(registry.Tasks(), second.ID())
+	assert.False(t, ok)
+}
+
+func TestRegistryNotRun(t *testing.T) {
+	registry := NewRegistry()
+	defer AddObserver(registry)()
+
+	// The tasks which are never run are never tracked
+	task := NewTask(func(context.Context) (interface{}, error) {
+		return nil, nil
+	})
+	p := NewPromise()
+	next := p.Task().ContinueWith(context.Background(), func(interface{}, error) (interface{}, error) {
+		return nil, nil
+	})
+
+	tasks := registry.Tasks()
+	for _, id := range []uint64{task.ID(), p.Task().ID(), next.ID()} {
+		_, ok := find(tasks, id)
+		assert.False(t, ok)
+	}
+}
+
+func TestRegistryWorkerPool(t *testing.T) {
+	registry := NewRegistry()
+	defer AddObserver(registry)()
+
+	p := NewWorkerPool(context.Background(), 1)
+	defer p.Stop()
+	release := make(chan struct{})
+	running := occupy(p, release)
+	queued := p.Submit(func(context.Context) (interface{}, error) {
+		return nil, nil
+	})
+
+	// The queued tasks are listed under the pool along with the running ones
+	info, ok := find(registry.Tasks(), p.Task().ID())
+	for ; !ok; info, ok = find(registry.Tasks(), p.Task().ID()) {
+		time.Sleep(time.Millisecond) // The pool is tracked once its task is running
+	}
+	assert.Equal(t, CombinatorPool, info.Combinator)
+	assert.Len(t, info.Members, 2)
+	assert.Equal(t, running.ID(), info.Members[0].ID)
+	assert.Equal(t, "running", info.Members[0].State)
+	assert.Equal(t, queued.ID(), info.Members[1].ID)
+	assert.Equal(t, "created", info.Members[1].State)
+
+	close(release)
+	_, _ = queued.Outcome()
+}
+
+func TestRegistryHTML(t *testing.T) {
+	registry := NewRegistry()
+	release := make(chan struct{})
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		<-release
+		return nil, nil
+	}, WithName("<running>"), WithLabels(map[string]string{"team": "x"}), WithObserver(registry))
+
+	for len(registry.Tasks()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	w := httptest.NewRecorder()
+	registry.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/async", nil))
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.True(t, strings.Contains(w.Body.String(), "&lt;running&gt;"))
+	assert.True(t, strings.Contains(w.Body.String(), "team=x"))
+
+	close(release)
+	_, _ = task.Outcome()
+	assert.Empty(t, registry.Tasks())
+}
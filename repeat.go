@@ -5,20 +5,22 @@ package async
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"runtime/debug"
 	"time"
 )
 
-// Repeat performs an action asynchronously on a predetermined interval.
-func Repeat(ctx context.Context, interval time.Duration, action Work) Task {
-	safeAction := func(ctx context.Context) (interface{}, error) {
-		defer handlePanic()
-		return action(ctx)
-	}
-
+// Repeat performs an action asynchronously on a predetermined interval. The options configure
+// the task of the timer, for example WithMetrics, which records every run of the action, or
+// WithLogger, which logs the panics of the action.
+func Repeat(ctx context.Context, interval time.Duration, action Work, opts ...TaskOption) Task {
 	// Invoke the task timer
-	return Invoke(ctx, func(taskCtx context.Context) (interface{}, error) {
+	return newCombinator(CombinatorRepeat, func(taskCtx context.Context, m meter) (interface{}, error) {
+		safeAction := func(ctx context.Context) (_ interface{}, err error) {
+			defer m.handlePanic(ctx, &err)
+			return action(ctx)
+		}
+
 		timer := time.NewTicker(interval)
 		for {
 			select {
@@ -26,16 +28,22 @@ func Repeat(ctx context.Context, interval time.Duration, action Work) Task {
 				timer.Stop()
 				return nil, nil
 
-			case <-timer.C:
-				_, _ = safeAction(taskCtx)
+			case tick := <-timer.C:
+				startedAt := now()
+				m.started(startedAt.Sub(tick))
+				_, err := safeAction(taskCtx)
+				m.finished(finalState(taskCtx, err), now().Sub(startedAt))
 			}
 		}
-	})
+	}, opts).Run(ctx)
 }
 
-// handlePanic handles the panic and logs it out.
-func handlePanic() {
+// handlePanic handles the panic of an action run by the combinator and logs it out, setting the
+// error to a *PanicError.
+func (m meter) handlePanic(ctx context.Context, err *error) {
 	if r := recover(); r != nil {
-		log.Printf("panic recovered: %ss \n %s", r, debug.Stack())
+		panicErr := &PanicError{Value: r, Stack: debug.Stack()}
+		m.owner.log(ctx, slog.LevelError, "action panicked", "panic", r, "stack", string(panicErr.Stack))
+		*err = panicErr
 	}
 }
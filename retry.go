@@ -0,0 +1,195 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Jitter represents the strategy used to randomize the backoff between attempts.
+type Jitter byte
+
+// Various jitter strategies
+const (
+	NoJitter    Jitter = iota // NoJitter waits for the exact backoff
+	FullJitter                // FullJitter waits for a random duration between zero and the backoff
+	EqualJitter               // EqualJitter waits for half of the backoff plus a random duration up to the other half
+)
+
+// RetryPolicy represents the policy used to retry work.
+type RetryPolicy struct {
+	MaxAttempts    int              // The maximum number of attempts, including the first one
+	InitialBackoff time.Duration    // The backoff before the second attempt
+	MaxBackoff     time.Duration    // The upper bound of the backoff, zero means no bound
+	Multiplier     float64          // The factor applied to the backoff after each attempt, defaults to 2
+	Jitter         Jitter           // The strategy used to randomize the backoff
+	AttemptTimeout time.Duration    // The timeout of each attempt, zero means no timeout
+	Retryable      func(error) bool // The classifier of retryable errors, defaults to every error
+}
+
+// RetryError represents the error of work which has failed after all its attempts.
+type RetryError struct {
+	Attempts int   // The number of attempts made
+	Err      error // The error of the last attempt
+	Cause    error // The error of the context, if it was done before the next attempt
+}
+
+// Error returns the error message.
+func (e *RetryError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("failed after %d attempt(s): %v (%v)", e.Attempts, e.Err, e.Cause)
+	}
+	return fmt.Sprintf("failed after %d attempt(s): %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the error of the last attempt and the error of the context, if any.
+func (e *RetryError) Unwrap() []error {
+	if e.Cause != nil {
+		return []error{e.Err, e.Cause}
+	}
+	return []error{e.Err}
+}
+
+// RetryResult represents the result of work which has succeeded after one or more attempts.
+type RetryResult struct {
+	Attempts int         // The number of attempts made, including the successful one
+	Result   interface{} // The result of the successful attempt
+}
+
+// WithRetry retries the work of the task according to the policy. The policy of a combinator is
+// also the policy of the tasks it runs which have no policy of their own, while the work of the
+// combinator itself is never retried.
+func WithRetry(policy RetryPolicy) TaskOption {
+	return func(t *task) {
+		t.retry = &policy
+	}
+}
+
+// getRetry returns the retry policy of the task, if any, otherwise the one of the combinator it is
+// a member of, if any.
+func (t *task) getRetry() *RetryPolicy {
+	if t.combinator != "" {
+		return nil
+	}
+
+	if t.retry != nil {
+		return t.retry
+	}
+
+	if owner := t.getOwner(); owner != nil {
+		return owner.retry
+	}
+	return nil
+}
+
+// Retry wraps the work so it is retried according to the policy. The wrapped work stops retrying
+// as soon as the error is not retryable or the context is done. Once it gives up, it returns a
+// *RetryError with the number of attempts and the error of the last attempt, along with the error
+// of the context if it is done.
+func Retry(policy RetryPolicy, work Work) Work {
+	return func(ctx context.Context) (interface{}, error) {
+		result, _, err := policy.retry(ctx, work)
+		return result, err
+	}
+}
+
+// RetryWithAttempts wraps the work so it is retried according to the policy, like Retry, except
+// the result of the wrapped work is a RetryResult which tells the number of attempts it took to
+// succeed.
+func RetryWithAttempts(policy RetryPolicy, work Work) Work {
+	return func(ctx context.Context) (interface{}, error) {
+		result, attempts, err := policy.retry(ctx, work)
+		if err != nil {
+			return result, err
+		}
+		return RetryResult{Attempts: attempts, Result: result}, nil
+	}
+}
+
+// retry executes the work until it succeeds or gives up, and returns the number of attempts made.
+func (p *RetryPolicy) retry(ctx context.Context, work Work) (interface{}, int, error) {
+	backoff := p.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		result, err := p.attempt(ctx, work)
+		if err == nil {
+			return result, attempt, nil
+		}
+
+		if attempt >= p.MaxAttempts || !p.retryable(err) {
+			return result, attempt, &RetryError{Attempts: attempt, Err: err}
+		}
+
+		if cause := p.wait(ctx, p.jitter(backoff)); cause != nil {
+			return result, attempt, &RetryError{Attempts: attempt, Err: err, Cause: cause}
+		}
+		backoff = p.next(backoff)
+	}
+}
+
+// wait waits for the backoff, unless the context is done in the meantime, in which case it
+// returns the error of the context. A context which is already done is never waited for.
+func (p *RetryPolicy) wait(ctx context.Context, backoff time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return ctx.Err()
+	}
+}
+
+// attempt executes the work once, with the attempt timeout if any.
+func (p *RetryPolicy) attempt(ctx context.Context, work Work) (interface{}, error) {
+	if p.AttemptTimeout <= 0 {
+		return work(ctx)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, p.AttemptTimeout)
+	defer cancel()
+	return work(attemptCtx)
+}
+
+// retryable returns whether the error should be retried.
+func (p *RetryPolicy) retryable(err error) bool {
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// next returns the backoff which follows the specified one.
+func (p *RetryPolicy) next(backoff time.Duration) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	next := time.Duration(float64(backoff) * multiplier)
+	if p.MaxBackoff > 0 && next > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return next
+}
+
+// jitter randomizes the backoff according to the jitter strategy.
+func (p *RetryPolicy) jitter(backoff time.Duration) time.Duration {
+	if backoff <= 0 {
+		return 0
+	}
+
+	switch p.Jitter {
+	case FullJitter:
+		return time.Duration(rand.Int63n(int64(backoff) + 1))
+	case EqualJitter:
+		half := backoff / 2
+		return half + time.Duration(rand.Int63n(int64(backoff-half)+1))
+	default:
+		return backoff
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	attempts := 0
+	work := Retry(RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Millisecond,
+		Jitter:         FullJitter,
+	}, func(context.Context) (interface{}, error) {
+		if attempts++; attempts < 3 {
+			return nil, errors.New("some error")
+		}
+		return attempts, nil
+	})
+
+	result, err := Invoke(context.Background(), work).Outcome()
+	assert.Equal(t, 3, result)
+	assert.Nil(t, err)
+}
+
+func TestRetryExhausted(t *testing.T) {
+	attempts := 0
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		attempts++
+		return nil, fmt.Errorf("error %d", attempts)
+	}, WithRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+	_, err := task.Outcome()
+	retryErr, ok := err.(*RetryError)
+	assert.True(t, ok)
+	assert.Equal(t, 3, retryErr.Attempts)
+	assert.EqualError(t, retryErr.Err, "error 3")
+	assert.Equal(t, "failed after 3 attempt(s): error 3", err.Error())
+}
+
+func TestRetryCombinator(t *testing.T) {
+	var attempts int32
+	tasks := []Task{
+		NewTask(func(context.Context) (interface{}, error) {
+			if atomic.AddInt32(&attempts, 1) < 3 {
+				return nil, errors.New("some error")
+			}
+			return 1, nil
+		}),
+		NewTask(func(context.Context) (interface{}, error) {
+			return 2, nil
+		}),
+	}
+
+	// The policy of the combinator applies to its members, rather than to its own work
+	result, err := ForkJoinResults(context.Background(), tasks, WithRetry(RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	})).Outcome()
+
+	assert.Equal(t, []interface{}{1, 2}, result)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestRetryNotRetryable(t *testing.T) {
+	fatal := errors.New("fatal")
+	attempts := 0
+	_, err := Retry(RetryPolicy{
+		MaxAttempts: 5,
+		Retryable: func(err error) bool {
+			return err != fatal
+		},
+	}, func(context.Context) (interface{}, error) {
+		attempts++
+		return nil, fatal
+	})(context.Background())
+
+	assert.True(t, errors.Is(err, fatal))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryAttemptTimeout(t *testing.T) {
+	_, err := Retry(RetryPolicy{
+		MaxAttempts:    2,
+		AttemptTimeout: 5 * time.Millisecond,
+	}, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})(context.Background())
+
+	assert.Equal(t, 2, err.(*RetryError).Attempts)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestRetryContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	t0 := time.Now()
+	_, err := Retry(RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: time.Second,
+	}, func(context.Context) (interface{}, error) {
+		return nil, errors.New("backend down")
+	})(ctx)
+
+	// The error of the last attempt is kept along with the error of the context
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, 1, err.(*RetryError).Attempts)
+	assert.EqualError(t, err.(*RetryError).Err, "backend down")
+	assert.EqualError(t, err, "failed after 1 attempt(s): backend down (context deadline exceeded)")
+	assert.True(t, time.Since(t0) < 500*time.Millisecond)
+}
+
+func TestRetryWithAttempts(t *testing.T) {
+	attempts := 0
+	result, err := Invoke(context.Background(), RetryWithAttempts(RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Millisecond,
+	}, func(context.Context) (interface{}, error) {
+		if attempts++; attempts < 3 {
+			return nil, errors.New("some error")
+		}
+		return "done", nil
+	})).Outcome()
+
+	assert.Equal(t, RetryResult{Attempts: 3, Result: "done"}, result)
+	assert.Nil(t, err)
+}
+
+func TestRetryContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A context which is already done is never retried, even without a backoff
+	for i := 0; i < 100; i++ {
+		attempts := 0
+		_, err := Retry(RetryPolicy{MaxAttempts: 10}, func(context.Context) (interface{}, error) {
+			attempts++
+			return nil, errors.New("some error")
+		})(ctx)
+
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Equal(t, 1, attempts)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 30 * time.Millisecond}
+	assert.Equal(t, 20*time.Millisecond, p.next(10*time.Millisecond))
+	assert.Equal(t, 30*time.Millisecond, p.next(20*time.Millisecond))
+
+	p.Jitter = EqualJitter
+	for i := 0; i < 100; i++ {
+		d := p.jitter(10 * time.Millisecond)
+		assert.True(t, d >= 5*time.Millisecond && d <= 10*time.Millisecond)
+	}
+}
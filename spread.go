@@ -5,47 +5,74 @@ package async
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"golang.org/x/time/rate"
 )
 
-// Throttle runs the tasks with a specified rate limiter.
-func Throttle(ctx context.Context, tasks []Task, rateLimit int, every time.Duration) Task {
-	return Invoke(ctx, func(context.Context) (interface{}, error) {
+// Throttle runs the tasks with a specified rate limiter. The options configure the task of
+// the throttler, for example WithMetrics. Once the throttler is cancelled or its context is done,
+// the tasks which are not started yet are cancelled.
+func Throttle(ctx context.Context, tasks []Task, rateLimit int, every time.Duration, opts ...TaskOption) Task {
+	return newCombinator(CombinatorThrottle, func(taskCtx context.Context, m meter) (interface{}, error) {
 		limiter := rate.NewLimiter(rate.Every(every/time.Duration(rateLimit)), 1)
 		for i, task := range tasks {
 			select {
-			case <-ctx.Done():
-				CancelAll(tasks[i:])
-				return nil, errCancelled
+			case <-taskCtx.Done():
+				cause := fmt.Errorf("throttle stopped: %w", taskCtx.Err())
+				m.dropped(taskCtx, cause, tasks[i:]...)
+				CancelAllWithCause(tasks[i:], cause)
+				return nil, &CancelledError{Cause: cause}
 			default:
-				if err := limiter.Wait(ctx); err == nil {
-					task.Run(ctx)
+				waitStart := now()
+				if err := limiter.Wait(taskCtx); err != nil {
+					cause := fmt.Errorf("throttle stopped: %w", err)
+					m.dropped(taskCtx, cause, task)
+					task.CancelWithCause(cause)
+					continue
 				}
+				m.owner.log(taskCtx, slog.LevelDebug, "rate limiter waited", "member_id", task.ID(), "wait", now().Sub(waitStart))
+				m.run(taskCtx, task)
 			}
 		}
 
 		WaitAll(tasks)
 		return nil, nil
-	})
+	}, opts).Run(ctx)
 }
 
-// Spread evenly spreads the work within the specified duration.
-func Spread(ctx context.Context, within time.Duration, tasks []Task) Task {
-	return Invoke(ctx, func(context.Context) (interface{}, error) {
+// Spread evenly spreads the work within the specified duration. The options configure the
+// task of the spreader, for example WithMetrics. Once the spreader is cancelled or its context is
+// done, the tasks which are not started yet are cancelled.
+func Spread(ctx context.Context, within time.Duration, tasks []Task, opts ...TaskOption) Task {
+	return newCombinator(CombinatorSpread, func(taskCtx context.Context, m meter) (interface{}, error) {
 		sleep := within / time.Duration(len(tasks))
-		for _, task := range tasks {
+		for i, task := range tasks {
 			select {
-			case <-ctx.Done():
-				return nil, errCancelled
+			case <-taskCtx.Done():
+				cause := fmt.Errorf("spread stopped: %w", taskCtx.Err())
+				m.dropped(taskCtx, cause, tasks[i:]...)
+				CancelAllWithCause(tasks[i:], cause)
+				return nil, &CancelledError{Cause: cause}
 			default:
-				task.Run(ctx)
-				time.Sleep(sleep)
+				m.run(taskCtx, task)
+				sleepCtx(taskCtx, sleep)
 			}
 		}
 
 		WaitAll(tasks)
 		return nil, nil
-	})
+	}, opts).Run(ctx)
+}
+
+// sleepCtx sleeps for the specified duration, unless the context is done in the meantime.
+func sleepCtx(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
 }
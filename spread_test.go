@@ -5,7 +5,9 @@ package async
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -40,7 +42,7 @@ func TestThrottle_Cancel(t *testing.T) {
 	cancel()
 
 	// Throttle and calculate the duration
-	Throttle(ctx, tasks, 3, 50*time.Millisecond)
+	task := Throttle(ctx, tasks, 3, 50*time.Millisecond)
 	WaitAll(tasks)
 	cancelled := 0
 	for _, task := range tasks {
@@ -50,6 +52,72 @@ func TestThrottle_Cancel(t *testing.T) {
 	}
 
 	assert.Equal(t, 5, cancelled)
+
+	_, err := tasks[0].Outcome()
+	assert.True(t, errors.Is(err, ErrCancelled))
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.EqualError(t, err, "context canceled: throttle stopped: context canceled")
+
+	_, _ = task.Outcome()
+	assert.Equal(t, IsCancelled, task.State())
+}
+
+// countingTasks creates tasks which count their runs
+func countingTasks(n int, runs *int32) []Task {
+	tasks := make([]Task, 0, n)
+	for i := 0; i < n; i++ {
+		tasks = append(tasks, NewTask(func(context.Context) (interface{}, error) {
+			atomic.AddInt32(runs, 1)
+			return nil, nil
+		}))
+	}
+	return tasks
+}
+
+func TestThrottle_CancelTask(t *testing.T) {
+	var runs int32
+	tasks := countingTasks(10, &runs)
+
+	task := Throttle(context.Background(), tasks, 1, 10*time.Millisecond)
+	time.Sleep(25 * time.Millisecond)
+	task.Cancel()
+	ran := atomic.LoadInt32(&runs)
+
+	// No task is started once the throttler is cancelled, except one being started meanwhile
+	WaitAll(tasks)
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, atomic.LoadInt32(&runs) <= ran+1)
+	assert.Equal(t, IsCancelled, tasks[9].State())
+}
+
+func TestSpread_CancelTask(t *testing.T) {
+	var runs int32
+	tasks := countingTasks(10, &runs)
+
+	task := Spread(context.Background(), 100*time.Millisecond, tasks)
+	time.Sleep(25 * time.Millisecond)
+	task.Cancel()
+	ran := atomic.LoadInt32(&runs)
+
+	// No task is started once the spreader is cancelled, except one being started meanwhile
+	WaitAll(tasks)
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, atomic.LoadInt32(&runs) <= ran+1)
+	assert.Equal(t, IsCancelled, tasks[9].State())
+}
+
+func TestSpread_Cancel(t *testing.T) {
+	tasks := newTasks()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	Spread(ctx, 50*time.Millisecond, tasks)
+	WaitAll(tasks)
+	for _, task := range tasks {
+		_, err := task.Outcome()
+		assert.EqualError(t, err, "context canceled: spread stopped: context canceled")
+	}
 }
 
 func TestSpread(t *testing.T) {
@@ -6,14 +6,27 @@ package async
 import (
 	"context"
 	"errors"
+	"fmt"
+	"runtime/debug"
+	"runtime/pprof"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
-var errCancelled = errors.New("context canceled")
+// ErrCancelled is the error of a cancelled task. The error of a task cancelled with a cause
+// is a *CancelledError, which matches ErrCancelled as well as its cause with errors.Is.
+var ErrCancelled = errors.New("context canceled")
+
+// ErrTaskTimeout is the error of a task which has exceeded its own timeout or deadline, as
+// opposed to the deadline of the context it was started with.
+var ErrTaskTimeout = errors.New("task timed out")
 
 var now = time.Now
 
+// lastID is the last identifier generated for a task
+var lastID uint64
+
 // Work represents a handler to execute
 type Work func(context.Context) (interface{}, error)
 
@@ -24,10 +37,50 @@ type State byte
 const (
 	IsCreated   State = iota // IsCreated represents a newly created task
 	IsRunning                // IsRunning represents a task which is currently running
-	IsCompleted              // IsCompleted represents a task which was completed successfully or errored out
+	IsCompleted              // IsCompleted represents a task which was completed successfully
 	IsCancelled              // IsCancelled represents a task which was cancelled or has timed out
+	IsFaulted                // IsFaulted represents a task which has errored out
 )
 
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case IsCreated:
+		return "created"
+	case IsRunning:
+		return "running"
+	case IsCompleted:
+		return "completed"
+	case IsCancelled:
+		return "cancelled"
+	case IsFaulted:
+		return "faulted"
+	default:
+		return fmt.Sprintf("State(%d)", s)
+	}
+}
+
+// Timing represents the timing of a task, which tells the time spent waiting to be run apart
+// from the time spent running.
+type Timing struct {
+	QueuedAt   time.Time     // The time the task was created
+	StartedAt  time.Time     // The time the task started running, zero if it never ran
+	FinishedAt time.Time     // The time the task was done, zero if it is not done yet
+	Duration   time.Duration // The duration of the run, from start to finish
+}
+
+// QueueDelay returns the time the task spent waiting to be run, up to now if it is still waiting.
+func (t Timing) QueueDelay() time.Duration {
+	switch {
+	case !t.StartedAt.IsZero():
+		return t.StartedAt.Sub(t.QueuedAt)
+	case !t.FinishedAt.IsZero():
+		return t.FinishedAt.Sub(t.QueuedAt)
+	default:
+		return now().Sub(t.QueuedAt)
+	}
+}
+
 type signal chan struct{}
 
 // Outcome of the task contains a result and an error
@@ -38,30 +91,133 @@ type outcome struct {
 
 // Task represents a unit of work to be done
 type task struct {
-	state    int32         // This indicates whether the task is started or not
-	cancel   signal        // The cancellation channel
-	done     signal        // The outcome channel
-	action   Work          // The work to do
-	outcome  outcome       // This is used to store the result
-	duration time.Duration // The duration of the task, in nanoseconds
+	sync.Mutex
+	id            uint64            // The unique identifier of the task
+	name          string            // The optional name of the task
+	labels        map[string]string // The metadata labels of the task
+	state         int32             // This indicates whether the task is started or not
+	cancel        signal            // The cancellation channel
+	done          signal            // The outcome channel
+	cancelErr     error             // The error of the cancellation, guarded by the mutex
+	action        Work              // The work to do
+	outcome       outcome           // This is used to store the result
+	timing        Timing            // The timing of the task, guarded by the mutex
+	repanic       bool              // Whether a panic in the work should crash the process
+	wait          bool              // Whether a cancelled task waits for its work to return
+	timeout       time.Duration     // The timeout of the task, starting when the task is run
+	deadline      time.Time         // The deadline of the task
+	external      bool              // Whether the task is started or completed by another party, rather than by Run
+	continuations []func()          // The callbacks to execute once the task is done
+	observers     []Observer        // The observers of the state transitions of the task
+	tracer        Tracer            // The tracer of the task, if different from the global one
+	span          Span              // The span of the task, guarded by the mutex
+	parent        *task             // The antecedent of a continuation
+	metrics       Metrics           // The metrics of the tasks run by a combinator, if different from the global ones
+	combinator    string            // The name of the combinator, if the task is the one of a combinator
+	owner         *task             // The task of the combinator the task is a member of, guarded by the mutex
+	logger        Logger            // The logger of the task, if different from the one of its combinator or the global one
+	executor      Executor          // The executor of the task, if different from the one of its combinator or the global one
+	stop          func()            // Cancels the context of the running task, guarded by the mutex
+	pending       func() []*task    // Lists the members of a combinator which are not running yet, such as the entries of a batch
+	retry         *RetryPolicy      // The retry policy of the work, if different from the one of its combinator
+}
+
+// TaskOption configures a task.
+type TaskOption func(*task)
+
+// WithPanicPropagation disables the panic recovery of the task, letting a panic in the
+// work crash the process instead of completing the task with a *PanicError.
+func WithPanicPropagation() TaskOption {
+	return func(t *task) {
+		t.repanic = true
+	}
+}
+
+// WithWaitForAction makes a cancelled task wait for its work to return before transitioning to
+// the cancelled state, so the goroutine executing the work has exited once the task is done.
+func WithWaitForAction() TaskOption {
+	return func(t *task) {
+		t.wait = true
+	}
+}
+
+// WithTimeout sets a timeout on the task, regardless of the context it is run with. The timeout
+// starts when the task is run, for example once a worker picks the task when run by InvokeAll or
+// Consume. A task which exceeds its timeout is cancelled with ErrTaskTimeout.
+func WithTimeout(timeout time.Duration) TaskOption {
+	return func(t *task) {
+		t.timeout = timeout
+	}
+}
+
+// WithDeadline sets a deadline on the task, regardless of the context it is run with. A task
+// which exceeds its deadline is cancelled with ErrTaskTimeout.
+func WithDeadline(deadline time.Time) TaskOption {
+	return func(t *task) {
+		t.deadline = deadline
+	}
+}
+
+// WithName sets the name of the task, which is inherited by its continuations.
+func WithName(name string) TaskOption {
+	return func(t *task) {
+		t.name = name
+	}
+}
+
+// WithLabels adds metadata labels to the task, which are inherited by its continuations.
+func WithLabels(labels map[string]string) TaskOption {
+	return func(t *task) {
+		if t.labels == nil {
+			t.labels = make(map[string]string, len(labels))
+		}
+		for k, v := range labels {
+			t.labels[k] = v
+		}
+	}
 }
 
 // Task represents a unit of work to be done
 type Task interface {
+	ID() uint64
+	Name() string
+	Labels() map[string]string
 	Run(ctx context.Context) Task
 	Cancel()
+	CancelWithCause(cause error)
 	State() State
+	Timing() Timing
 	Outcome() (interface{}, error)
-	ContinueWith(ctx context.Context, nextAction func(interface{}, error) (interface{}, error)) Task
+	Await(ctx context.Context) (interface{}, error)
+	Done() <-chan struct{}
+	ContinueWith(ctx context.Context, nextAction func(interface{}, error) (interface{}, error), opts ...ContinuationOptions) Task
+	Then(ctx context.Context, nextAction func(interface{}) (interface{}, error)) Task
+	Catch(ctx context.Context, nextAction func(error) (interface{}, error)) Task
+	Finally(action func(interface{}, error)) Task
+}
+
+// NewTask creates a new task. A panic in the action is recovered and completes the
+// task with a *PanicError, unless WithPanicPropagation option is specified.
+func NewTask(action Work, opts ...TaskOption) Task {
+	return newTask(action, opts...)
 }
 
-// NewTask creates a new task.
-func NewTask(action Work) Task {
-	return &task{
+// newTask creates a new task.
+func newTask(action Work, opts ...TaskOption) *task {
+	t := &task{
+		id:     atomic.AddUint64(&lastID, 1),
 		action: action,
 		done:   make(signal, 1),
 		cancel: make(signal, 1),
+		timing: Timing{QueuedAt: now()},
+	}
+
+	for _, opt := range opts {
+		opt(t)
 	}
+
+	t.notify(IsCreated, IsCreated)
+	return t
 }
 
 // NewTasks creates a set of new tasks.
@@ -74,8 +230,27 @@ func NewTasks(actions ...Work) []Task {
 }
 
 // Invoke creates a new tasks and runs it asynchronously.
-func Invoke(ctx context.Context, action Work) Task {
-	return NewTask(action).Run(ctx)
+func Invoke(ctx context.Context, action Work, opts ...TaskOption) Task {
+	return NewTask(action, opts...).Run(ctx)
+}
+
+// ID returns the unique identifier of the task, generated when the task is created.
+func (t *task) ID() uint64 {
+	return t.id
+}
+
+// Name returns the name of the task, if any.
+func (t *task) Name() string {
+	return t.name
+}
+
+// Labels returns a copy of the metadata labels of the task.
+func (t *task) Labels() map[string]string {
+	labels := make(map[string]string, len(t.labels))
+	for k, v := range t.labels {
+		labels[k] = v
+	}
+	return labels
 }
 
 // Outcome waits until the task is done and returns the final result and error.
@@ -84,6 +259,22 @@ func (t *task) Outcome() (interface{}, error) {
 	return t.outcome.result, t.outcome.err
 }
 
+// Await waits until the task is done and returns the final result and error. It returns
+// early with the error of the context if the context is done before the task.
+func (t *task) Await(ctx context.Context) (interface{}, error) {
+	select {
+	case <-t.done:
+		return t.outcome.result, t.outcome.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Done returns a channel which is closed once the task is done.
+func (t *task) Done() <-chan struct{} {
+	return t.done
+}
+
 // State returns the current state of the task. This operation is non-blocking.
 func (t *task) State() State {
 	v := atomic.LoadInt32(&t.state)
@@ -92,87 +283,258 @@ func (t *task) State() State {
 
 // Duration returns the duration of the task.
 func (t *task) Duration() time.Duration {
-	return t.duration
+	return t.Timing().Duration
+}
+
+// Timing returns the timing of the task. This operation is non-blocking.
+func (t *task) Timing() Timing {
+	t.Lock()
+	defer t.Unlock()
+	return t.timing
 }
 
-// Run starts the task asynchronously.
+// Run starts the task asynchronously, on its executor. The task of a combinator, which waits for
+// its members, runs on its own goroutine instead. A task started or completed by another party,
+// such as the task of a promise or a continuation, is not started by Run.
 func (t *task) Run(ctx context.Context) Task {
-	go t.run(ctx)
+	if t.external {
+		return t
+	}
+
+	if t.combinator != "" {
+		go t.run(ctx)
+		return t
+	}
+
+	t.schedule(ctx, t.run)
 	return t
 }
 
 // Cancel cancels a running task.
 func (t *task) Cancel() {
+	t.CancelWithCause(nil)
+}
+
+// CancelWithCause cancels a running task, recording the cause in the error of the task.
+func (t *task) CancelWithCause(cause error) {
+	err := ErrCancelled
+	if cause != nil {
+		err = &CancelledError{Cause: cause}
+	}
 
 	// If the task was created but never started, transition directly to cancelled state
 	// and close the done channel and set the error.
-	if t.changeState(IsCreated, IsCancelled) {
-		t.outcome = outcome{err: errCancelled}
-		close(t.done)
+	if t.settle(IsCancelled, outcome{err: err}) {
 		return
 	}
 
 	// Attempt to cancel the task if it's in the running state
-	if t.cancel != nil {
-		select {
-		case <-t.cancel:
-			return
-		default:
-			close(t.cancel)
+	t.Lock()
+	defer t.Unlock()
+	select {
+	case <-t.cancel:
+		return
+	default:
+		t.cancelErr = err
+		close(t.cancel)
+		if t.stop != nil {
+			t.stop()
 		}
 	}
 }
 
+// settle transitions a task which was never started directly to the specified final state
+// with the specified outcome. It returns false if the task was already started or done.
+func (t *task) settle(state State, o outcome) bool {
+	return t.finish(IsCreated, state, o)
+}
+
+// finish transitions the task to the specified final state with the specified outcome, closes
+// the done channel and executes the callbacks registered on the task. It returns false if the
+// task was not in the expected state.
+func (t *task) finish(from, to State, o outcome) bool {
+	if !t.changeState(from, to) {
+		return false
+	}
+
+	t.Lock()
+	t.outcome = o
+	t.timing.FinishedAt = now()
+	if !t.timing.StartedAt.IsZero() {
+		t.timing.Duration = t.timing.FinishedAt.Sub(t.timing.StartedAt)
+	}
+	timing := t.timing
+	t.Unlock()
+
+	t.endSpan(to, timing, o.err)
+
+	// Observers are notified before anyone waiting for the task
+	t.notify(from, to)
+
+	t.Lock()
+	close(t.done)
+	continuations := t.continuations
+	t.continuations = nil
+	t.Unlock()
+
+	for _, continuation := range continuations {
+		continuation()
+	}
+	return true
+}
+
+// onDone registers a callback to execute once the task is done. If the task is already done,
+// the callback is executed right away.
+func (t *task) onDone(callback func()) {
+	t.Lock()
+	select {
+	case <-t.done:
+		t.Unlock()
+		callback()
+	default:
+		t.continuations = append(t.continuations, callback)
+		t.Unlock()
+	}
+}
+
 // run starts the task synchronously.
 func (t *task) run(ctx context.Context) {
 	if !t.changeState(IsCreated, IsRunning) {
 		return // Prevent from running the same task twice
 	}
 
-	// Notify everyone of the completion/error state
-	defer close(t.done)
+	// The goroutines of the task are labelled for the profiles, and the labels of the
+	// calling goroutine are restored once the task is done
+	pprof.Do(ctx, t.profileLabels(), t.execute)
+}
+
+// execute executes the task, which is running, synchronously.
+func (t *task) execute(ctx context.Context) {
+	t.Lock()
+	t.timing.StartedAt = now()
+	t.Unlock()
+	t.notify(IsCreated, IsRunning)
 
-	// Execute the task
-	startedAt := now().UnixNano()
-	outcomeCh := make(chan outcome, 1)
-	go func() {
-		r, e := t.action(ctx)
-		outcomeCh <- outcome{result: r, err: e}
-	}()
+	// The work is given a context owned by the task, so it learns about the cancellation
+	// or the timeout of the task and stops once the task is done. The context carries the
+	// span of the task, so the tasks started by the work are traced as its children.
+	ctx = t.startSpan(ctx, t.parent)
+	taskCtx, cancel := t.context(ctx)
+	defer cancel()
 
+	// A manual cancellation cancels the context of the task, even if it happened before
+	t.Lock()
+	t.stop = cancel
 	select {
+	case <-t.cancel:
+		cancel()
+	default:
+	}
+	t.Unlock()
+
+	// In case of a manual cancellation, the context timeout or other error, or the timeout of
+	// the task, transition to the cancelled state right away, unless the task waits for its
+	// work to return.
+	stop := func() bool { return true }
+	if !t.wait {
+		stop = context.AfterFunc(taskCtx, func() {
+			t.finish(IsRunning, IsCancelled, t.cancelOutcome(ctx))
+		})
+	}
 
-	// In case of a manual task cancellation, set the outcome and transition
-	// to the cancelled state.
+	// Execute the work on the current goroutine, the one scheduled by the executor
+	o := t.invoke(taskCtx)
+	if !stop() {
+		return // The task was cancelled while the work was running
+	}
+
+	// Set the outcome, transition to the final state and notify everyone
+	select {
 	case <-t.cancel:
-		t.duration = time.Nanosecond * time.Duration(now().UnixNano()-startedAt)
-		t.outcome = outcome{err: errCancelled}
-		t.changeState(IsRunning, IsCancelled)
-		return
+		t.finish(IsRunning, IsCancelled, outcome{err: t.cancelErr})
+	default:
+		if t.wait && taskCtx.Err() != nil {
+			t.finish(IsRunning, IsCancelled, t.cancelOutcome(ctx))
+			return
+		}
 
-	// In case of the context timeout or other error, change the state of the
-	// task to cancelled and return right away.
-	case <-ctx.Done():
-		t.duration = time.Nanosecond * time.Duration(now().UnixNano()-startedAt)
-		t.outcome = outcome{err: ctx.Err()}
-		t.changeState(IsRunning, IsCancelled)
-		return
+		state := finalState(taskCtx, o.err)
+		if state == IsCancelled && ctx.Err() == nil && taskCtx.Err() == context.DeadlineExceeded {
+			o = outcome{err: ErrTaskTimeout} // The work gave up because of the task timeout
+		}
+		t.finish(IsRunning, state, o)
+	}
+}
 
-	// In case where we got an outcome (happy path)
-	case o := <-outcomeCh:
-		t.duration = time.Nanosecond * time.Duration(now().UnixNano()-startedAt)
-		t.outcome = o
-		t.changeState(IsRunning, IsCompleted)
-		return
+// invoke executes the work synchronously, recovering a panic unless the task propagates them.
+func (t *task) invoke(ctx context.Context) (o outcome) {
+	if !t.repanic {
+		defer t.recoverPanic(ctx, &o)
+	}
+
+	action := t.action
+	if policy := t.getRetry(); policy != nil {
+		action = Retry(*policy, action)
 	}
+
+	r, e := action(ctx)
+	return outcome{result: r, err: e}
 }
 
-// ContinueWith proceeds with the next task once the current one is finished.
-func (t *task) ContinueWith(ctx context.Context, nextAction func(interface{}, error) (interface{}, error)) Task {
-	return Invoke(ctx, func(context.Context) (interface{}, error) {
-		result, err := t.Outcome()
-		return nextAction(result, err)
-	})
+// cancelOutcome returns the outcome of a task whose context is done, given the context the task
+// was started with.
+func (t *task) cancelOutcome(ctx context.Context) outcome {
+	select {
+	case <-t.cancel:
+		return outcome{err: t.cancelErr}
+	default:
+		return outcome{err: contextErr(ctx)}
+	}
+}
+
+// context derives the context owned by the task from the specified one, applying the
+// timeout and the deadline of the task, if any.
+func (t *task) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline := t.deadline
+	if t.timeout > 0 && (deadline.IsZero() || now().Add(t.timeout).Before(deadline)) {
+		deadline = now().Add(t.timeout)
+	}
+
+	if deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline)
+}
+
+// contextErr returns the error of a task whose context is done, given the context the task was
+// started with. If that context is not done, the task has exceeded its own timeout.
+func contextErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return ErrTaskTimeout
+}
+
+// finalState returns the final state of a task whose work has returned the specified error. A
+// work which gives up because its context is done is considered cancelled rather than faulted.
+func finalState(ctx context.Context, err error) State {
+	switch {
+	case err == nil:
+		return IsCompleted
+	case ctx.Err() != nil && (errors.Is(err, ctx.Err()) || errors.Is(err, ErrCancelled)):
+		return IsCancelled
+	default:
+		return IsFaulted
+	}
+}
+
+// recoverPanic recovers a panic of the work, logs it and sets it as the outcome of the task.
+func (t *task) recoverPanic(ctx context.Context, o *outcome) {
+	if r := recover(); r != nil {
+		err := &PanicError{Value: r, Stack: debug.Stack()}
+		t.logPanic(ctx, err)
+		*o = outcome{err: err}
+	}
 }
 
 // Cancel cancels a running task.
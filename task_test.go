@@ -5,7 +5,10 @@ package async
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -100,7 +103,7 @@ func TestTaskCancelStarted(t *testing.T) {
 	task.Cancel()
 
 	_, err := task.Outcome()
-	assert.Equal(t, errCancelled, err)
+	assert.Equal(t, ErrCancelled, err)
 }
 
 func TestTaskCancelRunning(t *testing.T) {
@@ -114,7 +117,7 @@ func TestTaskCancelRunning(t *testing.T) {
 	task.Cancel()
 
 	_, err := task.Outcome()
-	assert.Equal(t, errCancelled, err)
+	assert.Equal(t, ErrCancelled, err)
 }
 
 func TestTaskCancelTwice(t *testing.T) {
@@ -130,5 +133,278 @@ func TestTaskCancelTwice(t *testing.T) {
 	})
 
 	_, err := task.Outcome()
-	assert.Equal(t, errCancelled, err)
+	assert.Equal(t, ErrCancelled, err)
+}
+
+func TestTaskPanic(t *testing.T) {
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		panic("test")
+	})
+
+	_, err := task.Outcome()
+	assert.Equal(t, IsFaulted, task.State())
+
+	panicErr, ok := err.(*PanicError)
+	assert.True(t, ok)
+	assert.Equal(t, "test", panicErr.Value)
+	assert.Contains(t, string(panicErr.Stack), "TestTaskPanic")
+	assert.Equal(t, "panic recovered: test", err.Error())
+}
+
+func TestTaskPanicError(t *testing.T) {
+	cause := errors.New("some error")
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		panic(cause)
+	})
+
+	_, err := task.Outcome()
+	assert.True(t, errors.Is(err, cause))
+}
+
+func TestTaskCancelPropagatesToWork(t *testing.T) {
+	workCancelled := make(chan struct{})
+	task := Invoke(context.Background(), func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		close(workCancelled)
+		return nil, ctx.Err()
+	})
+
+	time.Sleep(10 * time.Millisecond)
+	task.Cancel()
+	select {
+	case <-workCancelled:
+	case <-time.After(time.Second):
+		assert.Fail(t, "the context of the work was not cancelled")
+	}
+}
+
+func TestTaskWaitForAction(t *testing.T) {
+	var exited int32
+	task := Invoke(context.Background(), func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		atomic.StoreInt32(&exited, 1)
+		return nil, ctx.Err()
+	}, WithWaitForAction())
+
+	time.Sleep(10 * time.Millisecond)
+	task.Cancel()
+
+	_, err := task.Outcome()
+	assert.Equal(t, ErrCancelled, err)
+	assert.Equal(t, IsCancelled, task.State())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&exited))
+}
+
+func TestAwait(t *testing.T) {
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return 1, nil
+	})
+
+	result, err := task.Await(context.Background())
+	assert.Equal(t, 1, result)
+	assert.Nil(t, err)
+}
+
+func TestAwaitNeverStarted(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	task := NewTask(func(context.Context) (interface{}, error) {
+		return 1, nil
+	})
+
+	_, err := task.Await(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, IsCreated, task.State())
+}
+
+func TestDone(t *testing.T) {
+	first := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		time.Sleep(200 * time.Millisecond)
+		return 1, nil
+	})
+	second := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return 2, nil
+	})
+
+	select {
+	case <-first.Done():
+		assert.Fail(t, "the first task should not be done")
+	case <-second.Done():
+		result, _ := second.Outcome()
+		assert.Equal(t, 2, result)
+	}
+}
+
+func TestTaskFaulted(t *testing.T) {
+	task := Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return nil, errors.New("some error")
+	})
+
+	_, err := task.Outcome()
+	assert.EqualError(t, err, "some error")
+	assert.Equal(t, IsFaulted, task.State())
+}
+
+func TestTaskGivesUpOnContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	task := Invoke(ctx, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, fmt.Errorf("giving up: %w", ctx.Err())
+	})
+
+	_, _ = task.Outcome()
+	assert.Equal(t, IsCancelled, task.State())
+}
+
+func TestStateString(t *testing.T) {
+	assert.Equal(t, "created", IsCreated.String())
+	assert.Equal(t, "running", IsRunning.String())
+	assert.Equal(t, "completed", IsCompleted.String())
+	assert.Equal(t, "cancelled", IsCancelled.String())
+	assert.Equal(t, "faulted", IsFaulted.String())
+	assert.Equal(t, "State(42)", State(42).String())
+}
+
+func TestTaskCancelWithCause(t *testing.T) {
+	cause := errors.New("shutting down")
+	task := Invoke(context.Background(), func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	time.Sleep(10 * time.Millisecond)
+	task.CancelWithCause(cause)
+
+	_, err := task.Outcome()
+	assert.True(t, errors.Is(err, ErrCancelled))
+	assert.True(t, errors.Is(err, cause))
+	assert.EqualError(t, err, "context canceled: shutting down")
+	assert.Equal(t, IsCancelled, task.State())
+}
+
+func TestContinuationCarriesCause(t *testing.T) {
+	cause := errors.New("shutting down")
+	first := NewTask(func(context.Context) (interface{}, error) {
+		return 1, nil
+	})
+	next := first.Then(context.Background(), func(result interface{}) (interface{}, error) {
+		return result, nil
+	}).ContinueWith(context.Background(), func(result interface{}, err error) (interface{}, error) {
+		return result, err
+	}, NotOnCancelled)
+
+	first.CancelWithCause(cause)
+
+	_, err := next.Outcome()
+	assert.True(t, errors.Is(err, cause))
+	assert.Equal(t, IsCancelled, next.State())
+}
+
+func TestTaskTimeout(t *testing.T) {
+	task := Invoke(context.Background(), func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, WithTimeout(10*time.Millisecond))
+
+	_, err := task.Outcome()
+	assert.Equal(t, ErrTaskTimeout, err)
+	assert.Equal(t, IsCancelled, task.State())
+}
+
+func TestTaskDeadline(t *testing.T) {
+	task := Invoke(context.Background(), func(ctx context.Context) (interface{}, error) {
+		time.Sleep(500 * time.Millisecond)
+		return 1, nil
+	}, WithDeadline(time.Now().Add(10*time.Millisecond)))
+
+	_, err := task.Outcome()
+	assert.Equal(t, ErrTaskTimeout, err)
+}
+
+func TestTaskTimeoutParentFirst(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	task := Invoke(ctx, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, WithTimeout(time.Second))
+
+	_, err := task.Outcome()
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestTaskTimeoutPerItem(t *testing.T) {
+	tasks := make([]Task, 0, 4)
+	for i := 0; i < 4; i++ {
+		sleep := time.Duration(i%2) * 100 * time.Millisecond
+		tasks = append(tasks, NewTask(func(context.Context) (interface{}, error) {
+			time.Sleep(sleep)
+			return 1, nil
+		}, WithTimeout(50*time.Millisecond)))
+	}
+
+	// Each task gets its own budget, which starts once a worker picks it
+	_, err := InvokeAllResults(context.Background(), 1, tasks).Outcome()
+	assert.EqualError(t, err, "2 task(s) failed: task 1: task timed out; task 3: task timed out")
+}
+
+func TestTiming(t *testing.T) {
+	tasks := NewTasks(
+		sleepyWork(20*time.Millisecond, 1, nil),
+		sleepyWork(20*time.Millisecond, 2, nil),
+	)
+
+	notStarted := tasks[1].Timing()
+	assert.False(t, notStarted.QueuedAt.IsZero())
+	assert.True(t, notStarted.StartedAt.IsZero())
+
+	_, _ = InvokeAll(context.Background(), 1, tasks).Outcome()
+	WaitAll(tasks)
+
+	first, second := tasks[0].Timing(), tasks[1].Timing()
+	assert.True(t, first.Duration >= 20*time.Millisecond)
+	assert.Equal(t, first.FinishedAt.Sub(first.StartedAt), first.Duration)
+	assert.True(t, second.QueueDelay() >= first.Duration)
+	assert.False(t, second.StartedAt.Before(first.FinishedAt))
+}
+
+func TestTimingNeverStarted(t *testing.T) {
+	task := NewTask(sleepyWork(0, 1, nil))
+	task.Cancel()
+
+	timing := task.Timing()
+	assert.True(t, timing.StartedAt.IsZero())
+	assert.False(t, timing.FinishedAt.IsZero())
+	assert.Equal(t, time.Duration(0), timing.Duration)
+}
+
+func TestTaskIdentity(t *testing.T) {
+	first := NewTask(sleepyWork(0, 1, nil), WithName("first"), WithLabels(map[string]string{"k": "v"}))
+	second := NewTask(sleepyWork(0, 2, nil))
+
+	assert.NotEqual(t, first.ID(), second.ID())
+	assert.Equal(t, "first", first.Name())
+	assert.Equal(t, map[string]string{"k": "v"}, first.Labels())
+	assert.Equal(t, "", second.Name())
+	assert.Empty(t, second.Labels())
+
+	// Labels can't be altered from the outside
+	first.Labels()["k"] = "altered"
+	assert.Equal(t, "v", first.Labels()["k"])
+}
+
+func TestTaskIdentityInherited(t *testing.T) {
+	first := Invoke(context.Background(), sleepyWork(0, 1, nil), WithName("first"), WithLabels(map[string]string{"k": "v"}))
+	next := first.ContinueWith(context.Background(), func(result interface{}, err error) (interface{}, error) {
+		return result, err
+	})
+
+	assert.NotEqual(t, first.ID(), next.ID())
+	assert.Equal(t, "first", next.Name())
+	assert.Equal(t, map[string]string{"k": "v"}, next.Labels())
 }
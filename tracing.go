@@ -0,0 +1,127 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Span represents a traced operation, such as the run of a task.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	AddLink(linked Span)
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts the spans of the tasks. The asyncotel package provides an adapter for OpenTelemetry.
+type Tracer interface {
+	// Start starts a span as a child of the span carried by the context, if any, and returns
+	// a context carrying the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+
+	// ContextWithSpan returns a context carrying the span.
+	ContextWithSpan(ctx context.Context, span Span) context.Context
+}
+
+// Span attributes recorded for the tasks
+const (
+	AttributeTaskID       = "async.task.id"          // The identifier of the task
+	AttributeTaskState    = "async.task.state"       // The final state of the task
+	AttributeTaskDuration = "async.task.duration_ns" // The duration of the run, in nanoseconds
+	AttributeLabelPrefix  = "async.label."           // The prefix of the labels of the task
+)
+
+// defaultSpanName is the name of the span of a task without name
+const defaultSpanName = "async.task"
+
+var globalTracer atomic.Pointer[Tracer] // The global tracer, if any
+
+// SetTracer sets the tracer of every task which has no tracer of its own. A nil tracer disables tracing.
+func SetTracer(tracer Tracer) {
+	globalTracer.Store(&tracer)
+}
+
+// WithTracer sets the tracer of the task, which is inherited by its continuations.
+func WithTracer(tracer Tracer) TaskOption {
+	return func(t *task) {
+		t.tracer = tracer
+	}
+}
+
+// withParent sets the antecedent of a continuation.
+func withParent(parent *task) TaskOption {
+	return func(t *task) {
+		t.parent = parent
+	}
+}
+
+// getTracer returns the tracer of the task, if any, or the global one.
+func (t *task) getTracer() Tracer {
+	if t.tracer != nil {
+		return t.tracer
+	}
+
+	if tracer := globalTracer.Load(); tracer != nil {
+		return *tracer
+	}
+	return nil
+}
+
+// startSpan starts the span of the task, unless it was started already, and returns the context
+// carrying the span. The span is a child of the span of the parent task, if any, otherwise the
+// span carried by the context.
+func (t *task) startSpan(ctx context.Context, parent *task) context.Context {
+	tracer := t.getTracer()
+	if tracer == nil {
+		return ctx
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	if t.span != nil {
+		return tracer.ContextWithSpan(ctx, t.span)
+	}
+
+	if parent != nil {
+		if parentSpan := parent.getSpan(); parentSpan != nil {
+			ctx = tracer.ContextWithSpan(ctx, parentSpan)
+		}
+	}
+
+	name := t.name
+	if name == "" {
+		name = defaultSpanName
+	}
+
+	ctx, t.span = tracer.Start(ctx, name)
+	t.span.SetAttribute(AttributeTaskID, t.id)
+	for k, v := range t.labels {
+		t.span.SetAttribute(AttributeLabelPrefix+k, v)
+	}
+	return ctx
+}
+
+// getSpan returns the span of the task, if started.
+func (t *task) getSpan() Span {
+	t.Lock()
+	defer t.Unlock()
+	return t.span
+}
+
+// endSpan records the final state of the task and ends its span, if started.
+func (t *task) endSpan(state State, timing Timing, err error) {
+	span := t.getSpan()
+	if span == nil {
+		return
+	}
+
+	span.SetAttribute(AttributeTaskState, state.String())
+	span.SetAttribute(AttributeTaskDuration, int64(timing.Duration))
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
@@ -0,0 +1,159 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type spanKey struct{}
+
+// memorySpan is a span recorded in memory
+type memorySpan struct {
+	tracer     *memoryTracer
+	name       string
+	parent     *memorySpan
+	attributes map[string]interface{}
+	links      []*memorySpan
+	err        error
+	ended      bool
+}
+
+func (s *memorySpan) SetAttribute(key string, value interface{}) {
+	s.tracer.Lock()
+	defer s.tracer.Unlock()
+	s.attributes[key] = value
+}
+
+func (s *memorySpan) AddLink(linked Span) {
+	s.tracer.Lock()
+	defer s.tracer.Unlock()
+	s.links = append(s.links, linked.(*memorySpan))
+}
+
+func (s *memorySpan) RecordError(err error) {
+	s.tracer.Lock()
+	defer s.tracer.Unlock()
+	s.err = err
+}
+
+func (s *memorySpan) End() {
+	s.tracer.Lock()
+	defer s.tracer.Unlock()
+	s.ended = true
+}
+
+// memoryTracer records the spans in memory
+type memoryTracer struct {
+	sync.Mutex
+	spans []*memorySpan
+}
+
+func (t *memoryTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.Lock()
+	defer t.Unlock()
+	parent, _ := ctx.Value(spanKey{}).(*memorySpan)
+	span := &memorySpan{tracer: t, name: name, parent: parent, attributes: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (t *memoryTracer) ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// spanOf returns the span of the task
+func (t *memoryTracer) spanOf(task Task) *memorySpan {
+	t.Lock()
+	defer t.Unlock()
+	for _, span := range t.spans {
+		if span.attributes[AttributeTaskID] == task.ID() {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestTracing(t *testing.T) {
+	tracer := &memoryTracer{}
+	someErr := errors.New("some error")
+	task := Invoke(context.Background(), sleepyWork(0, nil, someErr), WithTracer(tracer),
+		WithName("traced"), WithLabels(map[string]string{"k": "v"}))
+	_, _ = task.Outcome()
+
+	span := tracer.spanOf(task)
+	assert.Equal(t, "traced", span.name)
+	assert.True(t, span.ended)
+	assert.Equal(t, someErr, span.err)
+	assert.Equal(t, "faulted", span.attributes[AttributeTaskState])
+	assert.Equal(t, "v", span.attributes[AttributeLabelPrefix+"k"])
+	assert.Contains(t, span.attributes, AttributeTaskDuration)
+}
+
+func TestTracingContinueWith(t *testing.T) {
+	tracer := &memoryTracer{}
+	first := Invoke(context.Background(), sleepyWork(0, 1, nil), WithTracer(tracer))
+	next := first.ContinueWith(context.Background(), func(result interface{}, err error) (interface{}, error) {
+		return result, err
+	})
+	_, _ = next.Outcome()
+
+	assert.Equal(t, tracer.spanOf(first), tracer.spanOf(next).parent)
+}
+
+func TestTracingCombinators(t *testing.T) {
+	tracer := &memoryTracer{}
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	forked := newTasks()
+	forkJoin := ForkJoin(context.Background(), forked)
+	_, _ = forkJoin.Outcome()
+
+	invoked := newTasks()
+	invokeAll := InvokeAll(context.Background(), 2, invoked)
+	_, _ = invokeAll.Outcome()
+
+	consumed := newTasks()
+	queue := make(chan Task, len(consumed))
+	for _, task := range consumed {
+		queue <- task
+	}
+	close(queue)
+	consume := Consume(context.Background(), 2, queue)
+	_, _ = consume.Outcome()
+
+	for parent, children := range map[Task][]Task{forkJoin: forked, invokeAll: invoked, consume: consumed} {
+		for _, child := range children {
+			assert.Equal(t, tracer.spanOf(parent), tracer.spanOf(child).parent)
+		}
+	}
+}
+
+func TestTracingBatch(t *testing.T) {
+	tracer := &memoryTracer{}
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	b := NewBatch(context.Background(), func(input []interface{}) []interface{} {
+		return input
+	}).(*batch)
+
+	entries := []Task{b.Append(1), b.Append(2)}
+	batchTask := b.batchTask
+	b.Reduce()
+	WaitAll(entries)
+
+	batchSpan := tracer.spanOf(batchTask)
+	assert.Len(t, batchSpan.links, 2)
+	for i, entry := range entries {
+		assert.Equal(t, tracer.spanOf(entry), batchSpan.links[i])
+		assert.True(t, tracer.spanOf(entry).ended)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+// Package typed provides a type-safe flavour of the async tasks. A typed task wraps
+// an async.Task, so it can be passed to any of the async combinators, while its
+// outcome is returned with a compile-time result type.
+package typed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/grab/async"
+)
+
+// Work represents a handler to execute which produces a result of type T
+type Work[T any] func(context.Context) (T, error)
+
+// Task represents a unit of work to be done which produces a result of type T
+type Task[T any] interface {
+	ID() uint64
+	Name() string
+	Labels() map[string]string
+	Run(ctx context.Context) Task[T]
+	Cancel()
+	CancelWithCause(cause error)
+	State() async.State
+	Timing() async.Timing
+	Outcome() (T, error)
+	Await(ctx context.Context) (T, error)
+	Done() <-chan struct{}
+	Untyped() async.Task
+}
+
+// task wraps an untyped task and converts its outcome
+type task[T any] struct {
+	inner async.Task
+}
+
+// NewTask creates a new typed task.
+func NewTask[T any](action Work[T], opts ...async.TaskOption) Task[T] {
+	return &task[T]{inner: async.NewTask(action.Untyped(), opts...)}
+}
+
+// NewTasks creates a set of new typed tasks.
+func NewTasks[T any](actions ...Work[T]) []Task[T] {
+	tasks := make([]Task[T], 0, len(actions))
+	for _, action := range actions {
+		tasks = append(tasks, NewTask(action))
+	}
+	return tasks
+}
+
+// Invoke creates a new typed task and runs it asynchronously.
+func Invoke[T any](ctx context.Context, action Work[T], opts ...async.TaskOption) Task[T] {
+	return NewTask(action, opts...).Run(ctx)
+}
+
+// From wraps an existing untyped task. If the outcome of the underlying task is not
+// of type T, Outcome returns an error instead of panicking.
+func From[T any](t async.Task) Task[T] {
+	return &task[T]{inner: t}
+}
+
+// Untyped returns the untyped tasks, to be used with the async combinators.
+func Untyped[T any](tasks []Task[T]) []async.Task {
+	out := make([]async.Task, 0, len(tasks))
+	for _, t := range tasks {
+		out = append(out, t.Untyped())
+	}
+	return out
+}
+
+// ContinueWith proceeds with the next action once the task is finished, see async.Task
+// ContinueWith for the options. Unlike the untyped version, the next action may change the
+// result type.
+func ContinueWith[T, U any](ctx context.Context, t Task[T], nextAction func(T, error) (U, error), opts ...async.ContinuationOptions) Task[U] {
+	return From[U](t.Untyped().ContinueWith(ctx, func(result interface{}, err error) (interface{}, error) {
+		return nextAction(convert[T](result, err))
+	}, opts...))
+}
+
+// Untyped converts the work into an untyped async.Work.
+func (w Work[T]) Untyped() async.Work {
+	return func(ctx context.Context) (interface{}, error) {
+		return w(ctx)
+	}
+}
+
+// ID returns the unique identifier of the task, generated when the task is created.
+func (t *task[T]) ID() uint64 {
+	return t.inner.ID()
+}
+
+// Name returns the name of the task, if any.
+func (t *task[T]) Name() string {
+	return t.inner.Name()
+}
+
+// Labels returns a copy of the metadata labels of the task.
+func (t *task[T]) Labels() map[string]string {
+	return t.inner.Labels()
+}
+
+// Run starts the task asynchronously.
+func (t *task[T]) Run(ctx context.Context) Task[T] {
+	t.inner.Run(ctx)
+	return t
+}
+
+// Cancel cancels a running task.
+func (t *task[T]) Cancel() {
+	t.inner.Cancel()
+}
+
+// CancelWithCause cancels a running task, recording the cause in the error of the task.
+func (t *task[T]) CancelWithCause(cause error) {
+	t.inner.CancelWithCause(cause)
+}
+
+// State returns the current state of the task. This operation is non-blocking.
+func (t *task[T]) State() async.State {
+	return t.inner.State()
+}
+
+// Timing returns the timing of the task. This operation is non-blocking.
+func (t *task[T]) Timing() async.Timing {
+	return t.inner.Timing()
+}
+
+// Outcome waits until the task is done and returns the final result and error.
+func (t *task[T]) Outcome() (T, error) {
+	return convert[T](t.inner.Outcome())
+}
+
+// Await waits until the task is done and returns the final result and error. It returns
+// early with the error of the context if the context is done before the task.
+func (t *task[T]) Await(ctx context.Context) (T, error) {
+	return convert[T](t.inner.Await(ctx))
+}
+
+// Done returns a channel which is closed once the task is done.
+func (t *task[T]) Done() <-chan struct{} {
+	return t.inner.Done()
+}
+
+// Untyped returns the underlying untyped task.
+func (t *task[T]) Untyped() async.Task {
+	return t.inner
+}
+
+// convert asserts the result to the expected type. A nil result, for example the
+// one of a cancelled task, is converted to the zero value of T. The error of the task, if any, is
+// kept along with the type error.
+func convert[T any](result interface{}, err error) (T, error) {
+	var zero T
+	if result == nil {
+		return zero, err
+	}
+
+	v, ok := result.(T)
+	if !ok {
+		expected := reflect.TypeOf((*T)(nil)).Elem()
+		typeErr := fmt.Errorf("typed: unexpected result type %T, expected %v", result, expected)
+		if err != nil {
+			return zero, errors.Join(err, typeErr)
+		}
+		return zero, typeErr
+	}
+	return v, err
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package typed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/grab/async"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutcome(t *testing.T) {
+	task := Invoke(context.Background(), func(context.Context) (int, error) {
+		return 1, nil
+	})
+
+	v, err := task.Outcome()
+	assert.Equal(t, 1, v)
+	assert.NoError(t, err)
+	assert.Equal(t, async.IsCompleted, task.State())
+}
+
+func TestContinueWithChangesType(t *testing.T) {
+	first := Invoke(context.Background(), func(context.Context) (int, error) {
+		return 42, nil
+	})
+
+	second := ContinueWith(context.Background(), first, func(v int, err error) (string, error) {
+		return strconv.Itoa(v), err
+	})
+
+	v, err := second.Outcome()
+	assert.Equal(t, "42", v)
+	assert.NoError(t, err)
+}
+
+func TestContinueWithOptions(t *testing.T) {
+	first := Invoke(context.Background(), func(context.Context) (int, error) {
+		return 0, errors.New("some error")
+	}, async.WithName("first"))
+
+	second := ContinueWith(context.Background(), first, func(v int, err error) (string, error) {
+		return strconv.Itoa(v), err
+	}, async.NotOnFaulted)
+
+	// The skipped continuation is cancelled and inherits the name of its antecedent
+	_, err := second.Outcome()
+	assert.True(t, errors.Is(err, async.ErrCancelled))
+	assert.Equal(t, async.IsCancelled, second.State())
+	assert.Equal(t, "first", second.Name())
+}
+
+func TestCancelledOutcomeIsZero(t *testing.T) {
+	task := NewTask(func(context.Context) (int, error) {
+		return 1, nil
+	})
+	task.Cancel()
+
+	v, err := task.Outcome()
+	assert.Equal(t, 0, v)
+	assert.Error(t, err)
+}
+
+func TestFromUnexpectedType(t *testing.T) {
+	untyped := async.Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return "not a number", nil
+	})
+
+	v, err := From[int](untyped).Outcome()
+	assert.Equal(t, 0, v)
+	assert.EqualError(t, err, "typed: unexpected result type string, expected int")
+}
+
+func TestFromUnexpectedTypeWithError(t *testing.T) {
+	backendErr := errors.New("backend down")
+	untyped := async.Invoke(context.Background(), func(context.Context) (interface{}, error) {
+		return "not a number", backendErr
+	})
+
+	// The error of the task is kept along with the type error
+	v, err := From[int](untyped).Outcome()
+	assert.Equal(t, 0, v)
+	assert.True(t, errors.Is(err, backendErr))
+	assert.Contains(t, err.Error(), "typed: unexpected result type string, expected int")
+}
+
+func TestForkJoinInterop(t *testing.T) {
+	tasks := NewTasks(
+		func(context.Context) (int, error) { return 1, nil },
+		func(context.Context) (int, error) { return 0, errors.New("some error") },
+	)
+
+	_, _ = async.ForkJoin(context.Background(), Untyped(tasks)).Outcome()
+
+	v1, err1 := tasks[0].Outcome()
+	assert.Equal(t, 1, v1)
+	assert.NoError(t, err1)
+
+	_, err2 := tasks[1].Outcome()
+	assert.EqualError(t, err2, "some error")
+}
+
+func ExampleContinueWith() {
+	task := Invoke(context.Background(), func(context.Context) (int, error) {
+		return 2, nil
+	})
+
+	doubled := ContinueWith(context.Background(), task, func(v int, err error) (string, error) {
+		return fmt.Sprintf("%d doubled is %d", v, v*2), err
+	})
+
+	fmt.Println(doubled.Outcome())
+
+	// Output:
+	// 2 doubled is 4 <nil>
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// AnyOutcome represents the result of the task returned by WhenAny.
+type AnyOutcome struct {
+	Index  int         // The index of the winning task
+	Result interface{} // The result of the winning task
+}
+
+// AnyOption configures the WhenAny combinator.
+type AnyOption func(*anyOptions)
+
+type anyOptions struct {
+	firstSuccess bool         // Whether only a successful task can win
+	cancelLosers bool         // Whether the other tasks are cancelled once there is a winner
+	taskOpts     []TaskOption // The options of the task of the combinator
+}
+
+// FirstSuccess makes WhenAny wait for the first task to succeed rather than the first task
+// to finish. If every task fails, the returned task fails with a MultiError.
+func FirstSuccess() AnyOption {
+	return func(o *anyOptions) {
+		o.firstSuccess = true
+	}
+}
+
+// CancelLosers makes WhenAny cancel all the other tasks once there is a winner.
+func CancelLosers() AnyOption {
+	return func(o *anyOptions) {
+		o.cancelLosers = true
+	}
+}
+
+// AnyTaskOptions sets the options of the task returned by WhenAny, for example WithExecutor, which
+// schedules the tasks on the executor, or WithMetrics.
+func AnyTaskOptions(opts ...TaskOption) AnyOption {
+	return func(o *anyOptions) {
+		o.taskOpts = append(o.taskOpts, opts...)
+	}
+}
+
+// WhenAny runs the tasks in parallel and waits for the first one to finish. The result of the
+// returned task is an AnyOutcome with the index and the result of the winning task, and its
+// error is the error of the winning task. If no tasks are specified, the outcome is empty. If
+// the returned task is cancelled or its context is done before there is a winner, the tasks are
+// cancelled as well.
+func WhenAny(ctx context.Context, tasks []Task, opts ...AnyOption) Task {
+	options := anyOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return newCombinator(CombinatorWhenAny, func(taskCtx context.Context, m meter) (interface{}, error) {
+		type indexedOutcome struct {
+			index int
+			outcome
+		}
+
+		outcomes := make(chan indexedOutcome, len(tasks))
+		for i, task := range tasks {
+			index := i
+			m.run(ctx, task).ContinueWith(ctx, func(result interface{}, err error) (interface{}, error) {
+				outcomes <- indexedOutcome{index: index, outcome: outcome{result: result, err: err}}
+				return nil, nil
+			}, ExecuteSynchronously)
+		}
+
+		var errs MultiError
+		for range tasks {
+			select {
+			case <-taskCtx.Done():
+				CancelAllWithCause(tasks, taskCtx.Err())
+				return nil, taskCtx.Err()
+
+			case o := <-outcomes:
+				if options.firstSuccess && o.err != nil {
+					errs = append(errs, &TaskError{Index: o.index, Err: o.err})
+					continue
+				}
+
+				if options.cancelLosers {
+					cause := fmt.Errorf("task %d won the race", o.index)
+					for i, task := range tasks {
+						if i != o.index {
+							task.CancelWithCause(cause)
+						}
+					}
+				}
+				return AnyOutcome{Index: o.index, Result: o.result}, o.err
+			}
+		}
+
+		if len(errs) == 0 {
+			return nil, nil
+		}
+
+		sort.Slice(errs, func(i, j int) bool {
+			return errs[i].Index < errs[j].Index
+		})
+		return nil, errs
+	}, options.taskOpts).Run(ctx)
+}
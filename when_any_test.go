@@ -0,0 +1,142 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sleepyWork(d time.Duration, result interface{}, err error) Work {
+	return func(context.Context) (interface{}, error) {
+		time.Sleep(d)
+		return result, err
+	}
+}
+
+func TestWhenAny(t *testing.T) {
+	tasks := NewTasks(
+		sleepyWork(200*time.Millisecond, 1, nil),
+		sleepyWork(10*time.Millisecond, 2, nil),
+	)
+
+	result, err := WhenAny(context.Background(), tasks).Outcome()
+	assert.Equal(t, AnyOutcome{Index: 1, Result: 2}, result)
+	assert.Nil(t, err)
+	assert.Equal(t, IsRunning, tasks[0].State())
+}
+
+func TestWhenAnyFirstError(t *testing.T) {
+	someErr := errors.New("some error")
+	tasks := NewTasks(
+		sleepyWork(200*time.Millisecond, 1, nil),
+		sleepyWork(10*time.Millisecond, nil, someErr),
+	)
+
+	result, err := WhenAny(context.Background(), tasks).Outcome()
+	assert.Equal(t, AnyOutcome{Index: 1}, result)
+	assert.Equal(t, someErr, err)
+}
+
+func TestWhenAnyFirstSuccess(t *testing.T) {
+	tasks := NewTasks(
+		sleepyWork(50*time.Millisecond, 1, nil),
+		sleepyWork(10*time.Millisecond, nil, errors.New("some error")),
+	)
+
+	result, err := WhenAny(context.Background(), tasks, FirstSuccess()).Outcome()
+	assert.Equal(t, AnyOutcome{Index: 0, Result: 1}, result)
+	assert.Nil(t, err)
+}
+
+func TestWhenAnyFirstSuccessAllFailed(t *testing.T) {
+	tasks := NewTasks(
+		sleepyWork(20*time.Millisecond, nil, errors.New("error 0")),
+		sleepyWork(10*time.Millisecond, nil, errors.New("error 1")),
+	)
+
+	result, err := WhenAny(context.Background(), tasks, FirstSuccess()).Outcome()
+	assert.Nil(t, result)
+	assert.EqualError(t, err, "2 task(s) failed: task 0: error 0; task 1: error 1")
+}
+
+func TestWhenAnyCancelLosers(t *testing.T) {
+	tasks := NewTasks(
+		sleepyWork(200*time.Millisecond, 1, nil),
+		sleepyWork(10*time.Millisecond, 2, nil),
+	)
+
+	result, err := WhenAny(context.Background(), tasks, CancelLosers()).Outcome()
+	assert.Equal(t, AnyOutcome{Index: 1, Result: 2}, result)
+	assert.Nil(t, err)
+
+	_, loserErr := tasks[0].Outcome()
+	assert.True(t, errors.Is(loserErr, ErrCancelled))
+	assert.EqualError(t, loserErr, "context canceled: task 1 won the race")
+	assert.Equal(t, IsCancelled, tasks[0].State())
+}
+
+func TestWhenAnyCancel(t *testing.T) {
+	started := make(chan struct{}, 2)
+	blocking := func(ctx context.Context) (interface{}, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	tasks := NewTasks(blocking, blocking)
+
+	task := WhenAny(context.Background(), tasks, CancelLosers())
+	<-started
+	<-started
+	task.Cancel()
+
+	// The racers are cancelled along with the race, which releases their work
+	WaitAll(tasks)
+	for _, racer := range tasks {
+		assert.Equal(t, IsCancelled, racer.State())
+	}
+	_, err := task.Outcome()
+	assert.Equal(t, ErrCancelled, err)
+}
+
+func TestWhenAnyTaskOptions(t *testing.T) {
+	var count int32
+	metrics := newMemoryMetrics()
+	tasks := NewTasks(
+		sleepyWork(10*time.Millisecond, 1, nil),
+		sleepyWork(10*time.Millisecond, 2, nil),
+	)
+
+	task := WhenAny(context.Background(), tasks, AnyTaskOptions(
+		WithName("race"), WithExecutor(countingExecutor(&count)), WithMetrics(metrics)))
+	_, err := task.Outcome()
+	WaitAll(tasks)
+
+	// The racers are scheduled on the executor and recorded as members
+	assert.Nil(t, err)
+	assert.Equal(t, "race", task.Name())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+	metrics.Lock()
+	defer metrics.Unlock()
+	assert.Equal(t, 2, metrics.started[CombinatorWhenAny])
+}
+
+func ExampleWhenAny() {
+	tasks := NewTasks(
+		sleepyWork(200*time.Millisecond, "origin", nil),
+		sleepyWork(10*time.Millisecond, "cache", nil),
+	)
+
+	result, _ := WhenAny(context.Background(), tasks, CancelLosers()).Outcome()
+	fmt.Println(result.(AnyOutcome).Result)
+
+	// Output:
+	// cache
+}
@@ -0,0 +1,292 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// ErrPoolFull is the error of a task rejected or dropped by a worker pool whose queue is full.
+var ErrPoolFull = errors.New("worker pool is full")
+
+// ErrPoolStopped is the error of a task submitted to a worker pool which is stopped, or of a
+// queued task cancelled when the pool is stopped.
+var ErrPoolStopped = errors.New("worker pool is stopped")
+
+// RejectionPolicy tells what a worker pool does with a task submitted while its queue is full.
+type RejectionPolicy byte
+
+// Various rejection policies
+const (
+	BlockWhenFull      RejectionPolicy = iota // BlockWhenFull blocks Submit until there is room in the queue
+	FailWhenFull                              // FailWhenFull fails the submitted task with ErrPoolFull
+	DropOldestWhenFull                        // DropOldestWhenFull cancels the oldest queued task with ErrPoolFull to make room
+	CallerRunsWhenFull                        // CallerRunsWhenFull runs the submitted task synchronously in Submit
+)
+
+// PoolOption configures a worker pool.
+type PoolOption func(*poolOptions)
+
+type poolOptions struct {
+	capacity int             // The maximum number of queued tasks, zero if unbounded
+	policy   RejectionPolicy // What to do with the tasks submitted while the queue is full
+	taskOpts []TaskOption    // The options of the task of the pool
+}
+
+// BoundedQueue bounds the number of tasks waiting for a worker, applying the rejection policy to
+// the tasks submitted while the queue is full. The queue of a pool is unbounded by default.
+func BoundedQueue(capacity int, policy RejectionPolicy) PoolOption {
+	return func(o *poolOptions) {
+		o.capacity = capacity
+		o.policy = policy
+	}
+}
+
+// PoolTaskOptions configures the task of the pool, which is running until the pool is stopped,
+// for example with WithMetrics, which records every task submitted to the pool, or WithLogger.
+func PoolTaskOptions(opts ...TaskOption) PoolOption {
+	return func(o *poolOptions) {
+		o.taskOpts = append(o.taskOpts, opts...)
+	}
+}
+
+// WorkerPool runs the submitted tasks on a resizable number of long-lived worker goroutines, so
+// many small jobs can share a concurrency limit without creating a pool for each of them, as
+// Consume and InvokeAll do. The tasks are run in the order they are submitted, each on a worker
+// from start to finish. The pool is stopped once its context is done.
+type WorkerPool struct {
+	sync.Mutex
+	ctx      context.Context // The context the tasks are run with
+	task     *task           // The task of the pool
+	meter    meter           // The meter of the tasks run by the pool
+	ready    *sync.Cond      // Signals the workers that a task was queued, the pool was resized or stopped
+	space    *sync.Cond      // Signals the blocked submitters that a task left the queue or the pool stopped
+	queue    []*task         // The tasks waiting for a worker
+	capacity int             // The maximum number of queued tasks, zero if unbounded
+	policy   RejectionPolicy // What to do with the tasks submitted while the queue is full
+	size     int             // The number of workers the pool should have
+	running  int             // The number of workers the pool has
+	closed   bool            // Whether the pool is stopped or draining
+	workers  sync.WaitGroup
+	stopped  signal // Closed once every worker has exited
+	once     sync.Once
+}
+
+// NewWorkerPool creates a worker pool with the specified number of workers, or the number of CPUs
+// if it is not positive. The pool must be stopped or drained once no longer used.
+func NewWorkerPool(ctx context.Context, workers int, opts ...PoolOption) *WorkerPool {
+	options := poolOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	p := &WorkerPool{
+		ctx:      ctx,
+		capacity: options.capacity,
+		policy:   options.policy,
+		stopped:  make(signal),
+	}
+	p.ready = sync.NewCond(&p.Mutex)
+	p.space = sync.NewCond(&p.Mutex)
+
+	// The task of the pool stops the pool if its context is done
+	p.task = newCombinator(CombinatorPool, func(taskCtx context.Context, _ meter) (interface{}, error) {
+		select {
+		case <-p.stopped:
+			return nil, nil
+		case <-taskCtx.Done():
+			p.shutdown(false)
+			return nil, taskCtx.Err()
+		}
+	}, append([]TaskOption{withPending(p.queued)}, options.taskOpts...))
+	p.meter = newMeter(p.task)
+
+	p.Resize(workers)
+	p.task.Run(ctx)
+	return p
+}
+
+// Task returns the task of the pool, which is done once the pool is stopped.
+func (p *WorkerPool) Task() Task {
+	return p.task
+}
+
+// Submit creates a task for the work and queues it to be run by a worker. If the queue is full,
+// the rejection policy of the pool applies. A task submitted to a stopped pool fails with
+// ErrPoolStopped. The options configure the task, whose executor is ignored as it is run by the
+// pool.
+func (p *WorkerPool) Submit(work Work, opts ...TaskOption) Task {
+	t := newTask(work, opts...)
+	t.setOwner(p.task)
+
+	p.Lock()
+	for p.policy == BlockWhenFull && p.full() && !p.closed {
+		p.space.Wait()
+	}
+
+	switch {
+	case p.closed:
+		p.Unlock()
+		p.reject(t, ErrPoolStopped)
+		return t
+
+	case !p.full():
+		p.queue = append(p.queue, t)
+		p.ready.Signal()
+		p.Unlock()
+		return t
+	}
+
+	switch p.policy {
+	case DropOldestWhenFull:
+		oldest := p.dequeue()
+		p.queue = append(p.queue, t)
+		p.ready.Signal()
+		p.Unlock()
+		p.drop(oldest, ErrPoolFull)
+
+	case CallerRunsWhenFull:
+		p.Unlock()
+		p.meter.execute(p.ctx, t)
+
+	default:
+		p.Unlock()
+		p.reject(t, ErrPoolFull)
+	}
+	return t
+}
+
+// QueueLength returns the number of tasks waiting for a worker.
+func (p *WorkerPool) QueueLength() int {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.queue)
+}
+
+// queued returns the tasks waiting for a worker.
+func (p *WorkerPool) queued() []*task {
+	p.Lock()
+	defer p.Unlock()
+	return append([]*task(nil), p.queue...)
+}
+
+// Size returns the number of workers of the pool.
+func (p *WorkerPool) Size() int {
+	p.Lock()
+	defer p.Unlock()
+	return p.size
+}
+
+// Resize changes the number of workers of the pool, or sets it to the number of CPUs if it is not
+// positive. The surplus workers exit once done with their current task.
+func (p *WorkerPool) Resize(workers int) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	if p.closed {
+		return
+	}
+
+	p.size = workers
+	for ; p.running < p.size; p.running++ {
+		p.workers.Add(1)
+		go p.work()
+	}
+	p.ready.Broadcast()
+}
+
+// Stop stops accepting tasks, cancels the queued tasks with ErrPoolStopped and waits for the
+// running tasks to finish.
+func (p *WorkerPool) Stop() {
+	p.shutdown(false)
+}
+
+// Drain stops accepting tasks and waits for the queued and running tasks to finish.
+func (p *WorkerPool) Drain() {
+	p.shutdown(true)
+}
+
+// shutdown stops the pool, cancelling the queued tasks unless draining them, and waits for the
+// workers to exit.
+func (p *WorkerPool) shutdown(drain bool) {
+	p.Lock()
+	p.closed = true
+	var dropped []*task
+	if !drain {
+		dropped, p.queue = p.queue, nil
+	}
+	p.ready.Broadcast()
+	p.space.Broadcast()
+	p.Unlock()
+
+	for _, t := range dropped {
+		p.drop(t, ErrPoolStopped)
+	}
+
+	p.workers.Wait()
+	p.once.Do(func() {
+		close(p.stopped)
+	})
+}
+
+// full returns whether the queue is full.
+func (p *WorkerPool) full() bool {
+	return p.capacity > 0 && len(p.queue) >= p.capacity
+}
+
+// dequeue removes the oldest task from the queue.
+func (p *WorkerPool) dequeue() *task {
+	t := p.queue[0]
+	p.queue[0] = nil
+	p.queue = p.queue[1:]
+	p.space.Signal()
+	return t
+}
+
+// drop cancels a queued task which will never be run.
+func (p *WorkerPool) drop(t *task, cause error) {
+	p.meter.dropped(p.ctx, cause, t)
+	t.CancelWithCause(cause)
+}
+
+// reject fails a task which was never queued.
+func (p *WorkerPool) reject(t *task, err error) {
+	p.meter.dropped(p.ctx, err, t)
+	t.settle(IsFaulted, outcome{err: err})
+}
+
+// work runs the queued tasks until the worker is surplus, or the pool is stopped and the queue
+// is empty.
+func (p *WorkerPool) work() {
+	defer p.workers.Done()
+	for {
+		p.Lock()
+		for len(p.queue) == 0 && !p.closed && p.running <= p.size {
+			p.ready.Wait()
+		}
+		if p.running > p.size || len(p.queue) == 0 {
+			p.running--
+			p.Unlock()
+			return
+		}
+
+		t := p.dequeue()
+		p.Unlock()
+
+		switch {
+		case t.State() != IsCreated:
+			// Skip the tasks cancelled while queued
+		case p.ctx.Err() != nil:
+			p.drop(t, p.ctx.Err())
+		default:
+			p.meter.execute(p.ctx, t)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright 2019 Grabtaxi Holdings PTE LTE (GRAB), All rights reserved.
+// Use of this source code is governed by an MIT-style license that can be found in the LICENSE file
+
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// occupy submits a task to the pool which holds a worker until released
+func occupy(p *WorkerPool, release chan struct{}) Task {
+	started := make(chan struct{})
+	task := p.Submit(func(context.Context) (interface{}, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	<-started
+	return task
+}
+
+func TestWorkerPool(t *testing.T) {
+	p := NewWorkerPool(context.Background(), 2)
+	defer p.Stop()
+
+	var running, maxRunning int32
+	tasks := make([]Task, 0, 6)
+	for i := 0; i < 6; i++ {
+		i := i
+		tasks = append(tasks, p.Submit(func(context.Context) (interface{}, error) {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return i, nil
+		}))
+	}
+
+	for i, task := range tasks {
+		v, err := task.Outcome()
+		assert.Equal(t, i, v)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+	assert.Equal(t, 2, p.Size())
+}
+
+func TestWorkerPoolResize(t *testing.T) {
+	p := NewWorkerPool(context.Background(), 1)
+	defer p.Stop()
+
+	release := make(chan struct{})
+	first := occupy(p, release)
+	second := p.Submit(func(context.Context) (interface{}, error) {
+		return 2, nil
+	})
+	assert.Equal(t, 1, p.QueueLength())
+
+	// A new worker runs the queued task while the first one is busy
+	p.Resize(2)
+	v, err := second.Outcome()
+	assert.Equal(t, 2, v)
+	assert.NoError(t, err)
+	assert.Equal(t, IsRunning, first.State())
+	close(release)
+
+	// The surplus worker exits and the remaining one keeps running the tasks
+	p.Resize(1)
+	assert.Equal(t, 1, p.Size())
+	v, err = p.Submit(func(context.Context) (interface{}, error) {
+		return 3, nil
+	}).Outcome()
+	assert.Equal(t, 3, v)
+	assert.NoError(t, err)
+}
+
+func TestWorkerPoolFailWhenFull(t *testing.T) {
+	p := NewWorkerPool(context.Background(), 1, BoundedQueue(1, FailWhenFull))
+	defer p.Stop()
+
+	release := make(chan struct{})
+	occupy(p, release)
+	queued := p.Submit(func(context.Context) (interface{}, error) {
+		return 1, nil
+	})
+	rejected := p.Submit(func(context.Context) (interface{}, error) {
+		return 2, nil
+	})
+
+	_, err := rejected.Outcome()
+	assert.Equal(t, IsFaulted, rejected.State())
+	assert.Equal(t, ErrPoolFull, err)
+	assert.Equal(t, 1, p.QueueLength())
+
+	close(release)
+	v, err := queued.Outcome()
+	assert.Equal(t, 1, v)
+	assert.NoError(t, err)
+}
+
+func TestWorkerPoolDropOldestWhenFull(t *testing.T) {
+	p := NewWorkerPool(context.Background(), 1, BoundedQueue(1, DropOldestWhenFull))
+	defer p.Stop()
+
+	release := make(chan struct{})
+	occupy(p, release)
+	oldest := p.Submit(func(context.Context) (interface{}, error) {
+		return 1, nil
+	})
+	newest := p.Submit(func(context.Context) (interface{}, error) {
+		return 2, nil
+	})
+
+	_, err := oldest.Outcome()
+	assert.Equal(t, IsCancelled, oldest.State())
+	assert.True(t, errors.Is(err, ErrPoolFull))
+
+	close(release)
+	v, err := newest.Outcome()
+	assert.Equal(t, 2, v)
+	assert.NoError(t, err)
+}
+
+func TestWorkerPoolCallerRunsWhenFull(t *testing.T) {
+	p := NewWorkerPool(context.Background(), 1, BoundedQueue(1, CallerRunsWhenFull))
+	defer p.Stop()
+
+	release := make(chan struct{})
+	defer close(release)
+	occupy(p, release)
+	p.Submit(func(context.Context) (interface{}, error) {
+		return 1, nil
+	})
+
+	// The task is done once submitted, as it ran on the calling goroutine
+	task := p.Submit(func(context.Context) (interface{}, error) {
+		return 2, nil
+	})
+	assert.Equal(t, IsCompleted, task.State())
+	assert.Equal(t, 1, p.QueueLength())
+}
+
+func TestWorkerPoolBlockWhenFull(t *testing.T) {
+	p := NewWorkerPool(context.Background(), 1, BoundedQueue(1, BlockWhenFull))
+	defer p.Stop()
+
+	release := make(chan struct{})
+	occupy(p, release)
+	p.Submit(func(context.Context) (interface{}, error) {
+		return 1, nil
+	})
+
+	submitted := make(chan Task)
+	go func() {
+		submitted <- p.Submit(func(context.Context) (interface{}, error) {
+			return 2, nil
+		})
+	}()
+
+	select {
+	case <-submitted:
+		assert.Fail(t, "submit should block while the queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	v, err := (<-submitted).Outcome()
+	assert.Equal(t, 2, v)
+	assert.NoError(t, err)
+}
+
+func TestWorkerPoolStop(t *testing.T) {
+	p := NewWorkerPool(context.Background(), 1)
+
+	release := make(chan struct{})
+	running := occupy(p, release)
+	queued := p.Submit(func(context.Context) (interface{}, error) {
+		return 1, nil
+	})
+
+	stopped := make(chan struct{})
+	go func() {
+		p.Stop()
+		close(stopped)
+	}()
+
+	// The queued task is cancelled, while the running one is waited for
+	_, err := queued.Outcome()
+	assert.True(t, errors.Is(err, ErrPoolStopped))
+	assert.Equal(t, IsCancelled, queued.State())
+	close(release)
+	<-stopped
+	assert.Equal(t, IsCompleted, running.State())
+
+	_, err = p.Submit(func(context.Context) (interface{}, error) {
+		return 2, nil
+	}).Outcome()
+	assert.Equal(t, ErrPoolStopped, err)
+
+	_, err = p.Task().Outcome()
+	assert.NoError(t, err)
+}
+
+func TestWorkerPoolDrain(t *testing.T) {
+	p := NewWorkerPool(context.Background(), 1)
+
+	release := make(chan struct{})
+	occupy(p, release)
+	queued := p.Submit(func(context.Context) (interface{}, error) {
+		return 1, nil
+	})
+	close(release)
+	p.Drain()
+
+	assert.Equal(t, IsCompleted, queued.State())
+	assert.Equal(t, 0, p.QueueLength())
+}
+
+func TestWorkerPoolContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewWorkerPool(ctx, 1)
+
+	running := p.Submit(func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	queued := p.Submit(func(context.Context) (interface{}, error) {
+		return 1, nil
+	})
+	cancel()
+
+	_, err := p.Task().Outcome()
+	assert.Equal(t, context.Canceled, err)
+	WaitAll([]Task{running, queued})
+	assert.Equal(t, IsCancelled, running.State())
+	assert.Equal(t, IsCancelled, queued.State())
+}
+
+func TestWorkerPoolMetrics(t *testing.T) {
+	metrics := newMemoryMetrics()
+	p := NewWorkerPool(context.Background(), 1, BoundedQueue(1, FailWhenFull), PoolTaskOptions(WithMetrics(metrics)))
+
+	release := make(chan struct{})
+	occupy(p, release)
+	p.Submit(func(context.Context) (interface{}, error) {
+		return nil, nil
+	})
+	p.Submit(func(context.Context) (interface{}, error) {
+		return nil, nil
+	})
+	close(release)
+	p.Drain()
+
+	metrics.Lock()
+	defer metrics.Unlock()
+	assert.Equal(t, 2, metrics.started[CombinatorPool])
+	assert.Equal(t, 2, metrics.finished[CombinatorPool][IsCompleted])
+	assert.Equal(t, 1, metrics.dropped[CombinatorPool])
+}
+
+func ExampleWorkerPool() {
+	pool := NewWorkerPool(context.Background(), 2, BoundedQueue(100, BlockWhenFull))
+	defer pool.Stop()
+
+	tasks := make([]Task, 0, 3)
+	for i := 1; i <= 3; i++ {
+		i := i
+		tasks = append(tasks, pool.Submit(func(context.Context) (interface{}, error) {
+			return i * i, nil
+		}))
+	}
+
+	for _, task := range tasks {
+		fmt.Println(task.Outcome())
+	}
+
+	// Output:
+	// 1 <nil>
+	// 4 <nil>
+	// 9 <nil>
+}